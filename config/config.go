@@ -2,11 +2,29 @@ package config
 
 import (
 	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v3"
 )
 
+// Config of gs. Getters of other config (e.g. GetOpenAPIConfig of `Configuration`) are optional,
+// and default values are used if they are not implemented. Embed `Configuration` inline to support them:
+//
+//	type MyConfig struct {
+//		config.Configuration `yaml:",inline"`
+//		Foo string `yaml:"foo"`
+//	}
+//
+//	func (config *MyConfig) SolveDefaultValue() {
+//		config.Configuration.SolveDefaultValue()
+//		// default value of Foo ...
+//	}
 type IConfiguration interface {
 	GetActiveEnv() string
 	GetGinRelease() bool
+	// host:port, or unix:/path/to/app.sock
 	GetGinAddr() string
 	GetSnowFlakeConfig() SnowFlakeConfig
 
@@ -19,14 +37,171 @@ type SnowFlakeConfig struct {
 	StartStmp    int64 `yaml:"start-stmp"`
 }
 
+type OpenAPIConfig struct {
+	Enable bool `yaml:"enable"`
+	// url path without extension, the document is served at {path}.json and {path}.yaml
+	Path    string          `yaml:"path"`
+	Title   string          `yaml:"title"`
+	Version string          `yaml:"version"`
+	UI      OpenAPIUIConfig `yaml:"ui"`
+}
+
+type OpenAPIUIConfig struct {
+	// it is ignored when gin.release is true, unless force is true
+	Enable bool   `yaml:"enable"`
+	Force  bool   `yaml:"force"`
+	Path   string `yaml:"path"`
+}
+
+type RoutesConfig struct {
+	// print route table when app starts
+	Print bool `yaml:"print"`
+	// url path of route listing endpoint, it is disabled if empty
+	Path string `yaml:"path"`
+}
+
+type ServerConfig struct {
+	TLS TLSConfig `yaml:"tls"`
+	// serve HTTP/2 without TLS (h2c), it is ignored if TLS is enabled
+	H2C bool `yaml:"h2c"`
+	// max time to wait for in-flight requests and running tasks when shutting down, e.g. 30s
+	ShutdownTimeout time.Duration `yaml:"shutdown-timeout"`
+	// default deadline of request context, 0 means no timeout. see `gs.Router.Timeout`
+	RequestTimeout time.Duration `yaml:"request-timeout"`
+	// status code when request timeout, 503 or 504. default value is 503
+	TimeoutStatus int `yaml:"timeout-status"`
+	// default max size of request body, larger requests are rejected with 413. default value is 32MB,
+	// and negative value means unlimited. see `gs.Router.MaxBodySize`
+	MaxBodySize ByteSize `yaml:"max-body-size"`
+	// max size of request headers. default value is 1MB
+	MaxHeaderSize ByteSize `yaml:"max-header-size"`
+	// Timeouts of connections, 0 means no timeout. default value of read-header-timeout is 10s
+	// and idle-timeout is 2m, which protect the server from slow clients.
+	ReadTimeout       time.Duration `yaml:"read-timeout"`
+	ReadHeaderTimeout time.Duration `yaml:"read-header-timeout"`
+	WriteTimeout      time.Duration `yaml:"write-timeout"`
+	IdleTimeout       time.Duration `yaml:"idle-timeout"`
+	// options of unix domain sockets, which are used when host is unix:/path/to/app.sock
+	UnixSocket UnixSocketConfig `yaml:"unix-socket"`
+}
+
+// Size in bytes, it can be written as 1024, 512KB, 10MB or 1GB in yaml.
+type ByteSize int64
+
+var byteSizeUnits = []struct {
+	suffix string
+	size   ByteSize
+}{
+	{"GB", 1 << 30},
+	{"MB", 1 << 20},
+	{"KB", 1 << 10},
+	{"B", 1},
+}
+
+func (size *ByteSize) UnmarshalYAML(node *yaml.Node) error {
+	text := strings.ToUpper(strings.TrimSpace(node.Value))
+	unit := ByteSize(1)
+	for _, item := range byteSizeUnits {
+		if number, ok := strings.CutSuffix(text, item.suffix); ok {
+			text, unit = strings.TrimSpace(number), item.size
+			break
+		}
+	}
+	value, err := strconv.ParseInt(text, 10, 64)
+	if err != nil {
+		return fmt.Errorf("invalid byte size %q", node.Value)
+	}
+	*size = ByteSize(value) * unit
+	return nil
+}
+
+type UnixSocketConfig struct {
+	// permission bits in octal, e.g. 0660. default value is decided by umask
+	Mode string `yaml:"mode"`
+	// user and group name (or id) of socket file, it is not changed if empty
+	Owner string `yaml:"owner"`
+	Group string `yaml:"group"`
+}
+
+type RedisConfig struct {
+	Addr     string `yaml:"addr"`
+	Password string `yaml:"password"`
+	DB       int    `yaml:"db"`
+}
+
+type RateLimitConfig struct {
+	// memory or redis (by `redis` config). default value is memory
+	Store string `yaml:"store"`
+}
+
+type CORSConfig struct {
+	// policy of all public routes, it can be overridden by `gs.Router.CORS`
+	Enable     bool `yaml:"enable"`
+	CORSPolicy `yaml:",inline"`
+}
+
+type CORSPolicy struct {
+	// exact origin (e.g. https://example.com), * for any origin, wildcard subdomain
+	// (e.g. https://*.example.com) or regular expression with regex: prefix
+	AllowOrigins []string `yaml:"allow-origins"`
+	// default value is GET, HEAD, POST, PUT, PATCH and DELETE
+	AllowMethods []string `yaml:"allow-methods"`
+	// headers requested by preflight are allowed if empty
+	AllowHeaders  []string `yaml:"allow-headers"`
+	ExposeHeaders []string `yaml:"expose-headers"`
+	// allow cookies and authorization headers, it can't be used with * in allow-origins
+	AllowCredentials bool `yaml:"allow-credentials"`
+	// how long preflight results can be cached, e.g. 10m. it is not sent if 0
+	MaxAge time.Duration `yaml:"max-age"`
+}
+
+type CompressionConfig struct {
+	// compress responses of all routers, it can be overridden by `gs.Router.Compression`
+	Enable bool `yaml:"enable"`
+	// 1 (best speed) to 9 (best compression). default value is 6
+	Level int `yaml:"level"`
+	// responses smaller than it are not compressed. default value is 1KB
+	MinSize ByteSize `yaml:"min-size"`
+	// prefixes of content types which are not compressed, besides images, videos, archives, etc.
+	ExcludeTypes []string `yaml:"exclude-types"`
+}
+
+type FeaturesConfig struct {
+	// status code of routes whose features are disabled, 404 or 503. default value is 404
+	DisabledStatus int `yaml:"disabled-status"`
+	// feature key => toggle. features not listed here are enabled
+	Toggles map[string]FeatureToggle `yaml:"toggles"`
+}
+
+type FeatureToggle struct {
+	Disable bool `yaml:"disable"`
+	// enabled only when env.active is one of them, it is ignored if empty
+	Envs []string `yaml:"envs"`
+}
+
+type TLSConfig struct {
+	// TLS is enabled if cert-file is not empty. cert and key are reloaded automatically when files change
+	CertFile string `yaml:"cert-file"`
+	KeyFile  string `yaml:"key-file"`
+	// 1.0, 1.1, 1.2 or 1.3. default value is 1.2
+	MinVersion string `yaml:"min-version"`
+	// names of cipher suites, e.g. TLS_ECDHE_RSA_WITH_AES_128_GCM_SHA256. default value is decided by go
+	CipherSuites []string `yaml:"cipher-suites"`
+	// CA bundle to verify client certificates, mTLS is enabled if it is not empty
+	ClientCAFile string `yaml:"client-ca-file"`
+	// request, require, verify-if-given or require-and-verify. default value is require-and-verify
+	ClientAuth string `yaml:"client-auth"`
+}
+
 type Configuration struct {
 	Env struct {
 		Active string `yaml:"active"`
 	} `yaml:"env"`
 	Gin struct {
-		Release bool   `yaml:"release"`
-		Host    string `yaml:"host"`
-		Port    int    `yaml:"port"`
+		Release bool `yaml:"release"`
+		// listen on unix domain socket if host is unix:/path/to/app.sock, and port is ignored
+		Host string `yaml:"host"`
+		Port int    `yaml:"port"`
 	} `yaml:"gin"`
 	Mysql struct {
 		Host      string `yaml:"host"`
@@ -36,11 +211,23 @@ type Configuration struct {
 		Database  string `yaml:"database"`
 		DebugMode bool   `yaml:"debug-mode"`
 	} `yaml:"mysql"`
-	Redis struct {
-		Addr     string `yaml:"addr"`
-		Password string `yaml:"password"`
-	} `yaml:"redis"`
+	Redis     RedisConfig     `yaml:"redis"`
 	SnowFlake SnowFlakeConfig `yaml:"snow-flake"`
+	OpenAPI   OpenAPIConfig   `yaml:"openapi"`
+	Routes    RoutesConfig    `yaml:"routes"`
+	Admin     struct {
+		// Admin endpoints are not authenticated and can change runtime state (e.g. feature toggles),
+		// so default value is 127.0.0.1. Set it to 0.0.0.0 (or an interface address) explicitly to
+		// expose them to other hosts. unix:/path/to/admin.sock is supported as well as gin.host
+		Host string `yaml:"host"`
+		// admin listener is disabled if port is 0 and host is not a unix socket
+		Port int `yaml:"port"`
+	} `yaml:"admin"`
+	Server      ServerConfig      `yaml:"server"`
+	Features    FeaturesConfig    `yaml:"features"`
+	RateLimit   RateLimitConfig   `yaml:"rate-limit"`
+	CORS        CORSConfig        `yaml:"cors"`
+	Compression CompressionConfig `yaml:"compression"`
 }
 
 func (config *Configuration) GetActiveEnv() string {
@@ -52,18 +239,114 @@ func (config *Configuration) GetGinRelease() bool {
 }
 
 func (config *Configuration) GetGinAddr() string {
+	if strings.HasPrefix(config.Gin.Host, "unix:") {
+		return config.Gin.Host
+	}
 	return fmt.Sprintf("%s:%d", config.Gin.Host, config.Gin.Port)
 }
 
+func (config *Configuration) GetAdminAddr() string {
+	if strings.HasPrefix(config.Admin.Host, "unix:") {
+		return config.Admin.Host
+	}
+	if config.Admin.Port == 0 {
+		return ""
+	}
+	return fmt.Sprintf("%s:%d", config.Admin.Host, config.Admin.Port)
+}
+
 func (config *Configuration) SolveDefaultValue() {
 	if config.Gin.Port == 0 {
 		config.Gin.Port = 5480
 	}
+	if config.Admin.Host == "" {
+		config.Admin.Host = "127.0.0.1"
+	}
 	if config.SnowFlake.StartStmp == 0 {
 		config.SnowFlake.StartStmp = 1626779686000
 	}
+	if config.OpenAPI.Path == "" {
+		config.OpenAPI.Path = "/openapi"
+	}
+	if config.OpenAPI.Title == "" {
+		config.OpenAPI.Title = "gin-stronger"
+	}
+	if config.OpenAPI.Version == "" {
+		config.OpenAPI.Version = "1.0.0"
+	}
+	if config.Server.ShutdownTimeout == 0 {
+		config.Server.ShutdownTimeout = 30 * time.Second
+	}
+	if config.Server.TimeoutStatus == 0 {
+		config.Server.TimeoutStatus = 503
+	}
+	if config.Server.MaxBodySize == 0 {
+		config.Server.MaxBodySize = 32 << 20
+	}
+	if config.Server.MaxHeaderSize == 0 {
+		config.Server.MaxHeaderSize = 1 << 20
+	}
+	if config.Server.ReadHeaderTimeout == 0 {
+		config.Server.ReadHeaderTimeout = 10 * time.Second
+	}
+	if config.Server.IdleTimeout == 0 {
+		config.Server.IdleTimeout = 2 * time.Minute
+	}
+	if config.Server.TLS.MinVersion == "" {
+		config.Server.TLS.MinVersion = "1.2"
+	}
+	if config.Server.TLS.ClientAuth == "" {
+		config.Server.TLS.ClientAuth = "require-and-verify"
+	}
+	if config.Features.DisabledStatus == 0 {
+		config.Features.DisabledStatus = 404
+	}
+	if config.RateLimit.Store == "" {
+		config.RateLimit.Store = "memory"
+	}
+	if config.Compression.Level == 0 {
+		config.Compression.Level = 6
+	}
+	if config.Compression.MinSize == 0 {
+		config.Compression.MinSize = 1 << 10
+	}
+	if config.OpenAPI.UI.Path == "" {
+		config.OpenAPI.UI.Path = "/api-explorer"
+	}
 }
 
 func (config *Configuration) GetSnowFlakeConfig() SnowFlakeConfig {
 	return config.SnowFlake
 }
+
+func (config *Configuration) GetOpenAPIConfig() OpenAPIConfig {
+	return config.OpenAPI
+}
+
+func (config *Configuration) GetRoutesConfig() RoutesConfig {
+	return config.Routes
+}
+
+func (config *Configuration) GetServerConfig() ServerConfig {
+	return config.Server
+}
+
+func (config *Configuration) GetFeaturesConfig() FeaturesConfig {
+	return config.Features
+}
+
+func (config *Configuration) GetRedisConfig() RedisConfig {
+	return config.Redis
+}
+
+func (config *Configuration) GetRateLimitConfig() RateLimitConfig {
+	return config.RateLimit
+}
+
+func (config *Configuration) GetCORSConfig() CORSConfig {
+	return config.CORS
+}
+
+func (config *Configuration) GetCompressionConfig() CompressionConfig {
+	return config.Compression
+}
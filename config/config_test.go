@@ -0,0 +1,59 @@
+package config
+
+import (
+	"testing"
+
+	"gopkg.in/yaml.v3"
+)
+
+func TestAdminAddr(t *testing.T) {
+	tests := []struct {
+		host string
+		port int
+		want string
+	}{
+		{"", 0, ""},
+		{"", 9090, "127.0.0.1:9090"},
+		{"0.0.0.0", 9090, "0.0.0.0:9090"},
+		{"unix:/run/admin.sock", 0, "unix:/run/admin.sock"},
+	}
+	for _, test := range tests {
+		config := &Configuration{}
+		config.Admin.Host, config.Admin.Port = test.host, test.port
+		config.SolveDefaultValue()
+		if got := config.GetAdminAddr(); got != test.want {
+			t.Errorf("host %q port %d: got %q, want %q", test.host, test.port, got, test.want)
+		}
+	}
+}
+
+type embeddedConfig struct {
+	Configuration `yaml:",inline"`
+	Foo           string `yaml:"foo"`
+}
+
+func (config *embeddedConfig) SolveDefaultValue() {
+	config.Configuration.SolveDefaultValue()
+	if config.Foo == "" {
+		config.Foo = "foo"
+	}
+}
+
+func TestEmbeddedConfiguration(t *testing.T) {
+	config := &embeddedConfig{}
+	var _ IConfiguration = config
+	data := "gin:\n  port: 8080\nopenapi:\n  title: demo\n"
+	if err := yaml.Unmarshal([]byte(data), config); err != nil {
+		t.Fatal(err)
+	}
+	config.SolveDefaultValue()
+	if got := config.GetGinAddr(); got != ":8080" {
+		t.Errorf("got addr %q", got)
+	}
+	if got := config.GetOpenAPIConfig(); got.Title != "demo" || got.Path != "/openapi" {
+		t.Errorf("got openapi config %+v", got)
+	}
+	if config.Foo != "foo" {
+		t.Errorf("got foo %q", config.Foo)
+	}
+}
@@ -0,0 +1,129 @@
+package gs
+
+import (
+	"net/http"
+	"runtime"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"gopkg.in/yaml.v3"
+)
+
+// routers served by admin listener only, see `UseAdminController`
+var adminRootRouter = Router{Path: "", admin: true}
+
+var startTime = time.Now()
+
+var metricsMutex sync.Mutex
+var metricsCollectors = make(map[string]func() any)
+
+// Register controller to admin listener (`admin.host` and `admin.port`),
+// so that it is never exposed on the public port.
+func UseAdminController(controller Controller) {
+	router := controller.GetRouter()
+	if router.controller == "" {
+		router.controller = getTypeName(controller)
+	}
+	adminRootRouter.Children = append(adminRootRouter.Children, router)
+}
+
+// Add a collector whose result is shown in the `metrics` of admin listener.
+func AddMetrics(name string, collector func() any) {
+	metricsMutex.Lock()
+	defer metricsMutex.Unlock()
+	metricsCollectors[name] = collector
+}
+
+type adminController struct{}
+
+func (adminController) GetRouter() Router {
+	return Router{
+		Path: "",
+		Children: []Router{
+			{Path: "/health", Handlers: []gin.HandlerFunc{adminHealth}},
+			{Path: "/metrics", Handlers: []gin.HandlerFunc{adminMetrics}},
+			{Path: "/config", Handlers: []gin.HandlerFunc{adminConfig}},
+			{Path: "/routes", Handlers: []gin.HandlerFunc{adminRoutes}},
+			{Path: "/features", Handlers: []gin.HandlerFunc{adminFeatures}},
+			{Path: "/features/:key", Method: PUT, Handlers: []gin.HandlerFunc{adminSetFeature}},
+		},
+	}
+}
+
+func adminHealth(c *gin.Context) {
+	c.JSON(http.StatusOK, gin.H{"status": "UP"})
+}
+
+func adminMetrics(c *gin.Context) {
+	var memStats runtime.MemStats
+	runtime.ReadMemStats(&memStats)
+	metrics := gin.H{
+		"uptime":     time.Since(startTime).String(),
+		"goroutines": runtime.NumGoroutine(),
+		"memory": gin.H{
+			"alloc":      memStats.Alloc,
+			"totalAlloc": memStats.TotalAlloc,
+			"sys":        memStats.Sys,
+			"numGC":      memStats.NumGC,
+		},
+	}
+
+	// collectors may take their own locks, so they are called outside metricsMutex
+	metricsMutex.Lock()
+	collectors := make(map[string]func() any, len(metricsCollectors))
+	for name, collector := range metricsCollectors {
+		collectors[name] = collector
+	}
+	metricsMutex.Unlock()
+	for name, collector := range collectors {
+		metrics[name] = collector()
+	}
+	c.JSON(http.StatusOK, metrics)
+}
+
+// Dump config, values of sensitive keys (e.g. password) are masked.
+func adminConfig(c *gin.Context) {
+	data, err := yaml.Marshal(Config)
+	if err != nil {
+		AbortWithError(c, http.StatusInternalServerError, err.Error())
+		return
+	}
+	var dump map[string]any
+	if err := yaml.Unmarshal(data, &dump); err != nil {
+		AbortWithError(c, http.StatusInternalServerError, err.Error())
+		return
+	}
+	maskSensitiveValues(dump)
+	c.JSON(http.StatusOK, dump)
+}
+
+func maskSensitiveValues(dump map[string]any) {
+	for key, value := range dump {
+		if child, ok := value.(map[string]any); ok {
+			maskSensitiveValues(child)
+			continue
+		}
+		lowerKey := strings.ToLower(key)
+		for _, word := range []string{"password", "secret", "token"} {
+			if strings.Contains(lowerKey, word) && value != "" {
+				dump[key] = "******"
+				break
+			}
+		}
+	}
+}
+
+func adminRoutes(c *gin.Context) {
+	c.JSON(http.StatusOK, Routes())
+}
+
+// Build gin engine of admin listener, including built-in endpoints (health, metrics,
+// config, routes and features) and routers registered by `UseAdminController`.
+func InitAdmin() *gin.Engine {
+	engine := gin.Default()
+	UseAdminController(adminController{})
+	AddRouter(engine, &adminRootRouter)
+	return engine
+}
@@ -0,0 +1,35 @@
+package gs
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+type hostTestController struct{}
+
+func (hostTestController) GetRouter() Router {
+	return Router{Path: "/health", Handlers: []gin.HandlerFunc{stringHandler("public")}}
+}
+
+func TestAdminIgnoresHostRouting(t *testing.T) {
+	setupTest(t, nil)
+	UseHostController("*.example.com", hostTestController{})
+	newTestHandler(t)
+	adminEngine := InitAdmin()
+
+	w := doRequest(adminEngine, testRequest{target: "/health", header: map[string]string{"Host": "admin.example.com:9090"}})
+	if w.Code != http.StatusOK || w.Body.String() != `{"status":"UP"}` {
+		t.Errorf("got %d %q, want admin health", w.Code, w.Body.String())
+	}
+}
+
+func TestAdminRejectsHost(t *testing.T) {
+	setupTest(t, nil)
+	UseAdminController(hostTestController{})
+	adminRootRouter.Children[0].Host = "admin.example.com"
+	if err := ValidateRouter(&adminRootRouter); err == nil {
+		t.Error("host of admin router is not reported")
+	}
+}
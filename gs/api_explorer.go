@@ -0,0 +1,35 @@
+package gs
+
+import (
+	_ "embed"
+	"encoding/json"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// All assets are embedded, so the explorer is available offline.
+//
+//go:embed assets/api-explorer.html
+var apiExplorerHtml string
+
+// API explorer is disabled in release mode, unless `openapi.ui.force` is true.
+func isAPIExplorerEnabled() bool {
+	cfg := getOpenAPIConfig().UI
+	if !cfg.Enable {
+		return false
+	}
+	return cfg.Force || !Config.GetGinRelease()
+}
+
+func initAPIExplorer(engine *gin.Engine, urlPath string, specUrl string) {
+	specUrlJson, err := json.Marshal(specUrl)
+	if err != nil {
+		panic(err)
+	}
+	page := []byte(strings.Replace(apiExplorerHtml, "{{SPEC_URL}}", string(specUrlJson), 1))
+	engine.GET(urlPath, func(c *gin.Context) {
+		c.Data(http.StatusOK, "text/html; charset=utf-8", page)
+	})
+}
@@ -0,0 +1,67 @@
+package gs
+
+import (
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/dan-kuroto/gin-stronger/config"
+)
+
+func TestAPIExplorerEnabled(t *testing.T) {
+	tests := []struct {
+		enable  bool
+		force   bool
+		release bool
+		want    bool
+	}{
+		{false, false, false, false},
+		{false, true, false, false},
+		{true, false, false, true},
+		{true, false, true, false},
+		{true, true, true, true},
+	}
+	for _, test := range tests {
+		setupTest(t, func(cfg *config.Configuration) {
+			cfg.OpenAPI.UI.Enable = test.enable
+			cfg.OpenAPI.UI.Force = test.force
+			cfg.Gin.Release = test.release
+		})
+		if got := isAPIExplorerEnabled(); got != test.want {
+			t.Errorf("enable=%v force=%v release=%v: got %v, want %v", test.enable, test.force, test.release, got, test.want)
+		}
+
+		// the document is served for the explorer, even if it is not enabled itself
+		handler := newTestHandler(t)
+		status := http.StatusNotFound
+		if test.want {
+			status = http.StatusOK
+		}
+		for _, path := range []string{"/api-explorer", "/openapi.json"} {
+			if w := doRequest(handler, testRequest{target: path}); w.Code != status {
+				t.Errorf("enable=%v force=%v release=%v: got %d for %s, want %d", test.enable, test.force, test.release, w.Code, path, status)
+			}
+		}
+	}
+}
+
+func TestAPIExplorerPage(t *testing.T) {
+	setupTest(t, func(cfg *config.Configuration) {
+		cfg.OpenAPI.Path = "/docs/openapi"
+		cfg.OpenAPI.UI.Enable = true
+		cfg.OpenAPI.UI.Path = "/docs"
+	})
+	handler := newTestHandler(t)
+
+	w := doRequest(handler, testRequest{target: "/docs"})
+	if w.Code != http.StatusOK || w.Header().Get("Content-Type") != "text/html; charset=utf-8" {
+		t.Fatalf("got %d %v", w.Code, w.Header())
+	}
+	page := w.Body.String()
+	if !strings.Contains(page, `var specUrl = "/docs/openapi.json";`) || strings.Contains(page, "{{SPEC_URL}}") {
+		t.Error("spec url is not filled in the page")
+	}
+	if w = doRequest(handler, testRequest{target: "/docs/openapi.json"}); w.Code != http.StatusOK {
+		t.Errorf("got %d for the document", w.Code)
+	}
+}
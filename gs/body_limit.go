@@ -0,0 +1,52 @@
+package gs
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// max body size of leaf router, 0 means unlimited
+func (scope *routerScope) getMaxBodySize() int64 {
+	if scope.maxBodySize != 0 || Config == nil {
+		return scope.maxBodySize
+	}
+	return int64(getServerConfig().MaxBodySize)
+}
+
+// limit set by `bodyLimitMiddleware`, it's shown in 413 response
+const maxBodySizeKey = "gs.max-body-size"
+
+// Respond 413 if Content-Length exceeds the limit, otherwise reading more than the limit
+// from body fails with *http.MaxBytesError (see `IsBodyTooLarge`).
+func bodyLimitMiddleware(maxBodySize int64) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if c.Request.ContentLength > maxBodySize {
+			abortWithBodyTooLarge(c, maxBodySize)
+			return
+		}
+		c.Set(maxBodySizeKey, maxBodySize)
+		c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, maxBodySize)
+	}
+}
+
+// Check whether the error is caused by body size limit, e.g. error of c.ShouldBind.
+func IsBodyTooLarge(err error) bool {
+	var maxBytesErr *http.MaxBytesError
+	return errors.As(err, &maxBytesErr)
+}
+
+// Respond 413 and return true if reading body failed because of body size limit.
+func abortIfBodyTooLarge(c *gin.Context, err error) bool {
+	if !IsBodyTooLarge(err) {
+		return false
+	}
+	abortWithBodyTooLarge(c, c.GetInt64(maxBodySizeKey))
+	return true
+}
+
+func abortWithBodyTooLarge(c *gin.Context, maxBodySize int64) {
+	AbortWithError(c, http.StatusRequestEntityTooLarge, fmt.Sprintf("request body is larger than %d bytes", maxBodySize))
+}
@@ -0,0 +1,56 @@
+package gs
+
+import (
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/dan-kuroto/gin-stronger/config"
+	"github.com/gin-gonic/gin"
+)
+
+type bodyLimitRequest struct {
+	Name string `json:"name"`
+}
+
+func TestBodyLimit(t *testing.T) {
+	setupTest(t, func(cfg *config.Configuration) {
+		cfg.Server.MaxBodySize = 16
+	})
+	rootRouter.Children = []Router{
+		{Path: "/bind", Method: POST, Handlers: PackageHandlers(func(req bodyLimitRequest) string { return req.Name })},
+		{Path: "/large", Method: POST, MaxBodySize: 1 << 10, Handlers: PackageHandlers(func(req bodyLimitRequest) string { return req.Name })},
+		{Path: "/unlimited", Method: POST, MaxBodySize: -1, Handlers: PackageHandlers(func(req bodyLimitRequest) string { return req.Name })},
+		{Path: "/idempotent", Method: POST, Idempotency: &Idempotency{}, Handlers: []gin.HandlerFunc{stringHandler("ok")}},
+	}
+	handler := newTestHandler(t)
+	large := `{"name":"` + strings.Repeat("a", 32) + `"}`
+	tooLarge := `{"status":413,"message":"request body is larger than 16 bytes"}`
+
+	tests := []struct {
+		path, body string
+		chunked    bool
+		status     int
+		response   string
+	}{
+		{"/bind", `{"name":"a"}`, false, http.StatusOK, `"a"`},
+		{"/bind", large, false, http.StatusRequestEntityTooLarge, tooLarge},
+		// Content-Length is unknown, so the limit is hit while binding
+		{"/bind", large, true, http.StatusRequestEntityTooLarge, tooLarge},
+		{"/large", large, true, http.StatusOK, `"` + strings.Repeat("a", 32) + `"`},
+		{"/unlimited", large, true, http.StatusOK, `"` + strings.Repeat("a", 32) + `"`},
+		{"/idempotent", large, true, http.StatusRequestEntityTooLarge, tooLarge},
+	}
+	for _, test := range tests {
+		header := map[string]string{"Content-Type": "application/json", "Idempotency-Key": "k"}
+		request := testRequest{method: http.MethodPost, target: test.path, body: test.body, header: header}
+		w := doRequestWith(handler, request, func(req *http.Request) {
+			if test.chunked {
+				req.ContentLength = -1
+			}
+		})
+		if w.Code != test.status || w.Body.String() != test.response {
+			t.Errorf("%s chunked=%v: got %d %q, want %d %q", test.path, test.chunked, w.Code, w.Body.String(), test.status, test.response)
+		}
+	}
+}
@@ -0,0 +1,279 @@
+package gs
+
+import (
+	"bytes"
+	"compress/gzip"
+	"compress/zlib"
+	"io"
+	"mime"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/gin-gonic/gin"
+	"github.com/rs/zerolog/log"
+)
+
+type CompressionMode int
+
+const (
+	// same as parent router, or `compression.enable` of config for root router
+	CompressionInherit CompressionMode = iota
+	CompressionOn
+	CompressionOff
+)
+
+// content types which are compressed already, compressing them again wastes CPU
+var compressedContentTypes = []string{
+	"image/", "video/", "audio/", "font/woff",
+	"application/zip", "application/gzip", "application/x-gzip", "application/x-7z-compressed",
+	"application/x-rar-compressed", "application/x-bzip2", "application/x-xz", "application/zstd",
+	"application/pdf", "application/octet-stream",
+}
+
+// except compressedContentTypes
+var compressibleImageTypes = []string{"image/svg+xml", "image/bmp", "image/x-icon"}
+
+type compressor struct {
+	level        int
+	minSize      int
+	excludeTypes []string
+	gzipPool     sync.Pool
+	zlibPool     sync.Pool
+}
+
+var defaultCompressor *compressor
+var defaultCompressorOnce sync.Once
+
+// compressor is shared by all routers, so that writers are pooled together
+func getCompressor() *compressor {
+	defaultCompressorOnce.Do(func() {
+		cfg := getCompressionConfig()
+		level := cfg.Level
+		if level < gzip.HuffmanOnly || level > gzip.BestCompression {
+			log.Warn().Int("level", level).Msg("invalid compression level, default level is used")
+			level = gzip.DefaultCompression
+		}
+		defaultCompressor = &compressor{level: level, minSize: int(cfg.MinSize), excludeTypes: cfg.ExcludeTypes}
+	})
+	return defaultCompressor
+}
+
+// compression of leaf router
+func (scope *routerScope) isCompressionEnabled() bool {
+	if scope.compression != CompressionInherit || Config == nil {
+		return scope.compression == CompressionOn
+	}
+	return getCompressionConfig().Enable
+}
+
+// Compress response by gzip or deflate according to Accept-Encoding.
+//
+// Response is buffered until `compression.min-size` bytes are written, and it's sent as is if it's
+// smaller than that or its content type is compressed already. Flush (e.g. by c.Stream or c.SSEvent)
+// compresses streaming responses immediately regardless of the size.
+func compressionMiddleware() gin.HandlerFunc {
+	compressor := getCompressor()
+	return func(c *gin.Context) {
+		c.Writer.Header().Add("Vary", "Accept-Encoding")
+		encoding := negotiateEncoding(c.GetHeader("Accept-Encoding"))
+		if encoding == "" || c.Request.Method == http.MethodHead {
+			return
+		}
+
+		writer := &compressWriter{ResponseWriter: c.Writer, compressor: compressor, encoding: encoding}
+		c.Writer = writer
+		defer func() {
+			writer.close()
+			c.Writer = writer.ResponseWriter
+		}()
+		c.Next()
+	}
+}
+
+// Choose gzip or deflate by q-values, gzip is preferred if they are equal.
+// It returns empty string if neither is acceptable.
+func negotiateEncoding(acceptEncoding string) string {
+	qualities := parseAcceptEncoding(acceptEncoding)
+	best, bestQuality := "", 0.0
+	for _, encoding := range []string{"gzip", "deflate"} {
+		if quality := qualities.of(encoding); quality > bestQuality {
+			best, bestQuality = encoding, quality
+		}
+	}
+	return best
+}
+
+// whether the encoding is acceptable by Accept-Encoding, i.e. its q-value is not 0
+func acceptsEncoding(acceptEncoding string, encoding string) bool {
+	return parseAcceptEncoding(acceptEncoding).of(encoding) > 0
+}
+
+// encoding => q-value
+type encodingQualities map[string]float64
+
+func parseAcceptEncoding(acceptEncoding string) encodingQualities {
+	qualities := make(encodingQualities)
+	for _, part := range strings.Split(acceptEncoding, ",") {
+		name, params, _ := strings.Cut(strings.TrimSpace(part), ";")
+		quality := 1.0
+		if value, ok := strings.CutPrefix(strings.TrimSpace(params), "q="); ok {
+			if q, err := strconv.ParseFloat(value, 64); err == nil {
+				quality = q
+			}
+		}
+		qualities[strings.ToLower(strings.TrimSpace(name))] = quality
+	}
+	return qualities
+}
+
+// q-value of encoding, or the one of * if it's not listed
+func (qualities encodingQualities) of(encoding string) float64 {
+	if quality, ok := qualities[encoding]; ok {
+		return quality
+	}
+	return qualities["*"]
+}
+
+func (compressor *compressor) isCompressible(contentType string) bool {
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		mediaType = strings.ToLower(contentType)
+	}
+	for _, prefix := range compressor.excludeTypes {
+		if strings.HasPrefix(mediaType, prefix) {
+			return false
+		}
+	}
+	for _, imageType := range compressibleImageTypes {
+		if mediaType == imageType {
+			return true
+		}
+	}
+	for _, prefix := range compressedContentTypes {
+		if strings.HasPrefix(mediaType, prefix) {
+			return false
+		}
+	}
+	return true
+}
+
+func (compressor *compressor) getWriter(encoding string, w io.Writer) io.WriteCloser {
+	if encoding == "gzip" {
+		if writer, ok := compressor.gzipPool.Get().(*gzip.Writer); ok {
+			writer.Reset(w)
+			return writer
+		}
+		// level is validated by getCompressor
+		writer, _ := gzip.NewWriterLevel(w, compressor.level)
+		return writer
+	}
+	// "deflate" coding of HTTP is zlib format rather than raw deflate (RFC 9110 8.4.1.2)
+	if writer, ok := compressor.zlibPool.Get().(*zlib.Writer); ok {
+		writer.Reset(w)
+		return writer
+	}
+	writer, _ := zlib.NewWriterLevel(w, compressor.level)
+	return writer
+}
+
+func (compressor *compressor) putWriter(writer io.WriteCloser) {
+	switch w := writer.(type) {
+	case *gzip.Writer:
+		compressor.gzipPool.Put(w)
+	case *zlib.Writer:
+		compressor.zlibPool.Put(w)
+	}
+}
+
+type compressWriter struct {
+	gin.ResponseWriter
+	compressor *compressor
+	encoding   string
+
+	// whether to compress is decided
+	decided bool
+	// nil if response is sent as is
+	writer io.WriteCloser
+	buffer bytes.Buffer
+}
+
+func (w *compressWriter) Write(data []byte) (int, error) {
+	if w.decided {
+		if w.writer != nil {
+			return w.writer.Write(data)
+		}
+		return w.ResponseWriter.Write(data)
+	}
+	w.buffer.Write(data)
+	if w.buffer.Len() >= w.compressor.minSize {
+		if err := w.decide(true); err != nil {
+			return 0, err
+		}
+	}
+	return len(data), nil
+}
+
+func (w *compressWriter) WriteString(s string) (int, error) {
+	return w.Write([]byte(s))
+}
+
+func (w *compressWriter) Written() bool {
+	return w.buffer.Len() > 0 || w.ResponseWriter.Written()
+}
+
+// Compress what is written so far and send it to client.
+func (w *compressWriter) Flush() {
+	if !w.decided {
+		w.decide(true)
+	}
+	if flusher, ok := w.writer.(interface{ Flush() error }); ok {
+		flusher.Flush()
+	}
+	w.ResponseWriter.Flush()
+}
+
+// Decide whether to compress by headers, and write the buffer.
+func (w *compressWriter) decide(compress bool) error {
+	w.decided = true
+	header := w.Header()
+	status := w.Status()
+	if header.Get("Content-Type") == "" && w.buffer.Len() != 0 {
+		// detect by uncompressed content, otherwise net/http detects it by compressed one
+		header.Set("Content-Type", http.DetectContentType(w.buffer.Bytes()))
+	}
+	if compress && header.Get("Content-Encoding") == "" && status != http.StatusNoContent &&
+		status != http.StatusNotModified && status >= http.StatusOK &&
+		w.compressor.isCompressible(header.Get("Content-Type")) {
+		header.Set("Content-Encoding", w.encoding)
+		header.Del("Content-Length")
+		// validator of uncompressed content can't be used for compressed one
+		if etag := header.Get("ETag"); etag != "" && !strings.HasPrefix(etag, "W/") {
+			header.Set("ETag", "W/"+etag)
+		}
+		w.writer = w.compressor.getWriter(w.encoding, w.ResponseWriter)
+	}
+	if w.buffer.Len() == 0 {
+		return nil
+	}
+	var err error
+	if w.writer != nil {
+		_, err = w.writer.Write(w.buffer.Bytes())
+	} else {
+		_, err = w.ResponseWriter.Write(w.buffer.Bytes())
+	}
+	w.buffer.Reset()
+	return err
+}
+
+// Send the rest of response, small response is sent without compression.
+func (w *compressWriter) close() {
+	if !w.decided {
+		w.decide(false)
+	}
+	if w.writer != nil {
+		w.writer.Close()
+		w.compressor.putWriter(w.writer)
+	}
+}
@@ -0,0 +1,90 @@
+package gs
+
+import (
+	"compress/gzip"
+	"compress/zlib"
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/dan-kuroto/gin-stronger/config"
+	"github.com/gin-gonic/gin"
+)
+
+func TestCompression(t *testing.T) {
+	setupTest(t, func(cfg *config.Configuration) {
+		cfg.Compression.Enable = true
+	})
+	large := strings.Repeat("hello gin-stronger ", 100)
+	rootRouter.Children = []Router{
+		{Path: "/large", Method: GET, Handlers: []gin.HandlerFunc{stringHandler(large)}},
+		{Path: "/small", Method: GET, Handlers: []gin.HandlerFunc{stringHandler("small")}},
+		{Path: "/image", Method: GET, Handlers: []gin.HandlerFunc{func(c *gin.Context) {
+			c.Data(http.StatusOK, "image/png", []byte(large))
+		}}},
+		{Path: "/off", Method: GET, Compression: CompressionOff, Handlers: []gin.HandlerFunc{stringHandler(large)}},
+	}
+	handler := newTestHandler(t)
+
+	tests := []struct {
+		path, acceptEncoding, encoding, body string
+	}{
+		{"/large", "gzip", "gzip", large},
+		{"/large", "gzip;q=0.5, deflate", "deflate", large},
+		{"/large", "gzip;q=0", "", large},
+		{"/large", "", "", large},
+		// smaller than min-size
+		{"/small", "gzip", "", "small"},
+		// compressed already
+		{"/image", "gzip", "", large},
+		{"/off", "gzip", "", large},
+	}
+	for _, test := range tests {
+		w := doRequest(handler, testRequest{target: test.path, header: map[string]string{"Accept-Encoding": test.acceptEncoding}})
+		if got := w.Header().Get("Content-Encoding"); got != test.encoding {
+			t.Errorf("%s %q: got Content-Encoding %q, want %q", test.path, test.acceptEncoding, got, test.encoding)
+			continue
+		}
+		var reader io.Reader = w.Body
+		switch test.encoding {
+		case "gzip":
+			gzipReader, err := gzip.NewReader(w.Body)
+			if err != nil {
+				t.Fatal(err)
+			}
+			reader = gzipReader
+		case "deflate":
+			zlibReader, err := zlib.NewReader(w.Body)
+			if err != nil {
+				t.Fatal(err)
+			}
+			reader = zlibReader
+		}
+		body, err := io.ReadAll(reader)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if string(body) != test.body {
+			t.Errorf("%s %q: body is changed", test.path, test.acceptEncoding)
+		}
+	}
+}
+
+func TestNegotiateEncoding(t *testing.T) {
+	tests := map[string]string{
+		"gzip, deflate":           "gzip",
+		"deflate":                 "deflate",
+		"deflate, gzip;q=0.8":     "deflate",
+		"*":                       "gzip",
+		"*, gzip;q=0":             "deflate",
+		"br":                      "",
+		"identity, gzip;q=0.0":    "",
+		"GZIP;q=1.0, deflate;q=1": "gzip",
+	}
+	for acceptEncoding, want := range tests {
+		if got := negotiateEncoding(acceptEncoding); got != want {
+			t.Errorf("%q: got %q, want %q", acceptEncoding, got, want)
+		}
+	}
+}
@@ -1,6 +1,7 @@
 package gs
 
 import (
+	"context"
 	"os"
 
 	"gopkg.in/yaml.v3"
@@ -12,7 +13,83 @@ import (
 // default config instance
 var Config config.IConfiguration
 
-var onConfigInitialized func()
+// It provides default values of config which is not supported by `Config`.
+var defaultConfig = newDefaultConfig()
+
+func newDefaultConfig() *config.Configuration {
+	cfg := &config.Configuration{}
+	cfg.SolveDefaultValue()
+	return cfg
+}
+
+// Getters below are optional methods of `Config`, default values are used if they are not implemented.
+// (`config.Configuration` implements all of them)
+
+func getOpenAPIConfig() config.OpenAPIConfig {
+	if provider, ok := Config.(interface{ GetOpenAPIConfig() config.OpenAPIConfig }); ok {
+		return provider.GetOpenAPIConfig()
+	}
+	return defaultConfig.GetOpenAPIConfig()
+}
+
+func getRoutesConfig() config.RoutesConfig {
+	if provider, ok := Config.(interface{ GetRoutesConfig() config.RoutesConfig }); ok {
+		return provider.GetRoutesConfig()
+	}
+	return defaultConfig.GetRoutesConfig()
+}
+
+// admin listener is disabled if it's empty
+func getAdminAddr() string {
+	if provider, ok := Config.(interface{ GetAdminAddr() string }); ok {
+		return provider.GetAdminAddr()
+	}
+	return defaultConfig.GetAdminAddr()
+}
+
+func getServerConfig() config.ServerConfig {
+	if provider, ok := Config.(interface{ GetServerConfig() config.ServerConfig }); ok {
+		return provider.GetServerConfig()
+	}
+	return defaultConfig.GetServerConfig()
+}
+
+func getFeaturesConfig() config.FeaturesConfig {
+	if provider, ok := Config.(interface{ GetFeaturesConfig() config.FeaturesConfig }); ok {
+		return provider.GetFeaturesConfig()
+	}
+	return defaultConfig.GetFeaturesConfig()
+}
+
+func getRedisConfig() config.RedisConfig {
+	if provider, ok := Config.(interface{ GetRedisConfig() config.RedisConfig }); ok {
+		return provider.GetRedisConfig()
+	}
+	return defaultConfig.GetRedisConfig()
+}
+
+func getRateLimitConfig() config.RateLimitConfig {
+	if provider, ok := Config.(interface{ GetRateLimitConfig() config.RateLimitConfig }); ok {
+		return provider.GetRateLimitConfig()
+	}
+	return defaultConfig.GetRateLimitConfig()
+}
+
+func getCORSConfig() config.CORSConfig {
+	if provider, ok := Config.(interface{ GetCORSConfig() config.CORSConfig }); ok {
+		return provider.GetCORSConfig()
+	}
+	return defaultConfig.GetCORSConfig()
+}
+
+func getCompressionConfig() config.CompressionConfig {
+	if provider, ok := Config.(interface {
+		GetCompressionConfig() config.CompressionConfig
+	}); ok {
+		return provider.GetCompressionConfig()
+	}
+	return defaultConfig.GetCompressionConfig()
+}
 
 // Load config from application.yml, application-{env}.yml and cmd parameters.
 // (`env` is given by application.yml)
@@ -55,7 +132,16 @@ func initConfigByYaml[T config.IConfiguration](config T, baseName string, env st
 	return nil
 }
 
-// task() will be called after config is inited
+// task() will be called after config is inited.
+//
+// It is shorthand for `AddHook` with PhaseConfigLoaded, so it can be called multiple times.
 func OnConfigInitialized(task func()) {
-	onConfigInitialized = task
+	AddHook(Hook{
+		Name:  "OnConfigInitialized",
+		Phase: PhaseConfigLoaded,
+		Func: func(context.Context) error {
+			task()
+			return nil
+		},
+	})
 }
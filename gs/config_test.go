@@ -0,0 +1,35 @@
+package gs
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/dan-kuroto/gin-stronger/config"
+	"github.com/gin-gonic/gin"
+)
+
+// configuration which implements only required methods of config.IConfiguration
+type minimalConfig struct{}
+
+func (minimalConfig) GetActiveEnv() string                       { return "" }
+func (minimalConfig) GetGinRelease() bool                        { return false }
+func (minimalConfig) GetGinAddr() string                         { return ":5480" }
+func (minimalConfig) GetSnowFlakeConfig() config.SnowFlakeConfig { return config.SnowFlakeConfig{} }
+func (minimalConfig) SolveDefaultValue()                         {}
+
+func TestOptionalConfigGetters(t *testing.T) {
+	setupTest(t, nil)
+	Config = minimalConfig{}
+	rootRouter.Children = []Router{{Path: "/ping", Method: GET, Handlers: []gin.HandlerFunc{stringHandler("pong")}}}
+	handler := newTestHandler(t)
+
+	if w := doRequest(handler, testRequest{target: "/ping"}); w.Code != http.StatusOK || w.Body.String() != "pong" {
+		t.Errorf("got %d %q", w.Code, w.Body.String())
+	}
+	if addr := getAdminAddr(); addr != "" {
+		t.Errorf("got admin addr %q, want disabled", addr)
+	}
+	if cfg := getServerConfig(); cfg.MaxBodySize != 32<<20 || cfg.TimeoutStatus != http.StatusServiceUnavailable {
+		t.Errorf("got server config %+v, want default", cfg)
+	}
+}
@@ -0,0 +1,210 @@
+package gs
+
+import (
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+	"unicode"
+
+	"github.com/gin-gonic/gin"
+)
+
+type RouteSpec struct {
+	// path of router group. default value is kebab-case type name without "Controller",
+	// e.g. /user-order for UserOrderController
+	Path        string
+	MiddleWares []gin.HandlerFunc
+	// method name => "{METHOD} {path}", e.g. "ListUsers": "GET /list".
+	// It takes precedence over the naming convention, and "-" means the method is not a handler.
+	// It panics if the method is not exported by the controller.
+	Routes map[string]string
+	// Only methods declared by `Routes` are handlers, so that exported helper methods like
+	// GetDB aren't exposed by the naming convention.
+	ExplicitOnly bool
+}
+
+// Optional interface of convention-based controller, to customize the generated router.
+type RouteSpecProvider interface {
+	RouteSpec() RouteSpec
+}
+
+var conventionMethodPrefixes = []struct {
+	prefix string
+	method HttpMethod
+}{
+	{"Get", GET},
+	{"Head", HEAD},
+	{"Post", POST},
+	{"Put", PUT},
+	{"Patch", PATCH},
+	{"Delete", DELETE},
+	{"Options", OPTIONS},
+}
+
+// Build router by exported methods of controller, which are set as `Router.Function`.
+// Method name is {HttpMethod}{Path}[By{Param}...], for example:
+//
+//	Get()                   => GET    (path of router group)
+//	GetList()               => GET    /list
+//	GetOrderItemsByID()     => GET    /order-items/:id
+//	PostOrder()             => POST   /order
+//	DeleteByUserIdByName()  => DELETE /:userId/:name
+//
+// ATTENTION: every exported method matching the convention becomes a route, including helpers
+// like GetDB. Exclude them by "-" in `RouteSpec.Routes`, or set `RouteSpec.ExplicitOnly`.
+// Methods which don't match the convention are ignored, unless they are declared by `RouteSpec`.
+//
+// Route names are {package}.{type}.{method}, e.g. controller.UserController.GetList.
+func NewConventionRouter(controller any) Router {
+	value := reflect.ValueOf(controller)
+	typ := value.Type()
+	typeName := typ.Name()
+	// qualified by package, so that controllers of different packages don't conflict
+	qualifiedName := typ.String()
+	if typ.Kind() == reflect.Ptr {
+		typeName = typ.Elem().Name()
+		qualifiedName = typ.Elem().String()
+	}
+
+	spec := RouteSpec{}
+	if provider, ok := controller.(RouteSpecProvider); ok {
+		spec = provider.RouteSpec()
+	}
+	if spec.Path == "" {
+		spec.Path = "/" + camelToKebab(strings.TrimSuffix(typeName, "Controller"))
+	}
+
+	router := Router{Path: spec.Path, MiddleWares: spec.MiddleWares}
+	methodNames := make([]string, 0, typ.NumMethod())
+	for i := 0; i < typ.NumMethod(); i++ {
+		methodNames = append(methodNames, typ.Method(i).Name)
+	}
+	sort.Strings(methodNames)
+	// e.g. typo, unexported method or method of pointer receiver while controller is not a pointer
+	specNames := make([]string, 0, len(spec.Routes))
+	for methodName := range spec.Routes {
+		specNames = append(specNames, methodName)
+	}
+	sort.Strings(specNames)
+	for _, methodName := range specNames {
+		if _, ok := typ.MethodByName(methodName); !ok {
+			panic(fmt.Sprintf("%s.%s: no exported method for route spec %q", qualifiedName, methodName, spec.Routes[methodName]))
+		}
+	}
+	for _, methodName := range methodNames {
+		var method HttpMethod
+		var path string
+		if routeSpec, ok := spec.Routes[methodName]; ok {
+			if routeSpec == "-" {
+				continue
+			}
+			method, path = parseRouteSpec(qualifiedName, methodName, routeSpec)
+		} else if spec.ExplicitOnly || methodName == "GetRouter" || methodName == "RouteSpec" {
+			continue
+		} else if method, path, ok = parseConventionMethod(methodName); !ok {
+			continue
+		}
+		function := value.MethodByName(methodName).Interface()
+		if _, err := newHandlerInfo(function); err != nil {
+			panic(fmt.Sprintf("%s.%s: %v", qualifiedName, methodName, err))
+		}
+		router.Children = append(router.Children, Router{
+			Path:     path,
+			Method:   method,
+			Function: function,
+			Name:     qualifiedName + "." + methodName,
+			// name of method value is meaningless, e.g. reflect.methodValueCall
+			functionName: qualifiedName + "." + methodName,
+		})
+	}
+	return router
+}
+
+// Register controller by `NewConventionRouter`.
+func UseConventionController(controller any) {
+	router := NewConventionRouter(controller)
+	router.controller = getTypeName(controller)
+	rootRouter.Children = append(rootRouter.Children, router)
+}
+
+// e.g. "GET /user/:id"
+func parseRouteSpec(typeName, methodName, routeSpec string) (HttpMethod, string) {
+	methodText, path, _ := strings.Cut(strings.TrimSpace(routeSpec), " ")
+	for _, item := range httpMethodNames {
+		if strings.EqualFold(item.name, methodText) {
+			return item.method, strings.TrimSpace(path)
+		}
+	}
+	panic(fmt.Sprintf("%s.%s: invalid route spec %q", typeName, methodName, routeSpec))
+}
+
+func parseConventionMethod(methodName string) (HttpMethod, string, bool) {
+	for _, item := range conventionMethodPrefixes {
+		rest, ok := strings.CutPrefix(methodName, item.prefix)
+		if !ok || (rest != "" && !unicode.IsUpper(rune(rest[0]))) {
+			continue
+		}
+
+		parts := splitByParams(rest)
+		segments := make([]string, 0, len(parts))
+		if parts[0] != "" {
+			segments = append(segments, camelToKebab(parts[0]))
+		}
+		for _, param := range parts[1:] {
+			segments = append(segments, ":"+lowerFirstWord(param))
+		}
+		if len(segments) == 0 {
+			return item.method, "", true
+		}
+		return item.method, "/" + strings.Join(segments, "/"), true
+	}
+	return 0, "", false
+}
+
+// e.g. OrderByUserIdByName => [Order, UserId, Name]
+func splitByParams(name string) []string {
+	parts := make([]string, 0, 2)
+	start := 0
+	for i := 0; i+2 < len(name); i++ {
+		if name[i:i+2] == "By" && unicode.IsUpper(rune(name[i+2])) && (i == 0 || !unicode.IsUpper(rune(name[i-1]))) {
+			parts = append(parts, name[start:i])
+			start = i + 2
+			i++
+		}
+	}
+	return append(parts, name[start:])
+}
+
+// e.g. UserID => user-id, HTTPServer => http-server
+func camelToKebab(name string) string {
+	return strings.Join(splitCamelWords(name), "-")
+}
+
+// e.g. UserId => userId, ID => id
+func lowerFirstWord(name string) string {
+	words := splitCamelWords(name)
+	if len(words) == 0 {
+		return ""
+	}
+	return words[0] + name[len(words[0]):]
+}
+
+// split camel case name to lower case words
+func splitCamelWords(name string) []string {
+	words := make([]string, 0)
+	runes := []rune(name)
+	start := 0
+	for i := 1; i < len(runes); i++ {
+		// lower => Upper, or UPPer => UPP + Per
+		if unicode.IsUpper(runes[i]) &&
+			(!unicode.IsUpper(runes[i-1]) || (i+1 < len(runes) && unicode.IsLower(runes[i+1]))) {
+			words = append(words, strings.ToLower(string(runes[start:i])))
+			start = i
+		}
+	}
+	if start < len(runes) {
+		words = append(words, strings.ToLower(string(runes[start:])))
+	}
+	return words
+}
@@ -0,0 +1,109 @@
+package gs
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+type conventionTestController struct{}
+
+func (conventionTestController) GetList(c *gin.Context) {
+	c.String(http.StatusOK, "list")
+}
+
+func (conventionTestController) GetByID(c *gin.Context) {
+	c.String(http.StatusOK, "user "+c.Param("id"))
+}
+
+type explicitTestController struct{}
+
+func (explicitTestController) RouteSpec() RouteSpec {
+	return RouteSpec{Path: "/explicit", ExplicitOnly: true, Routes: map[string]string{"List": "GET /list"}}
+}
+
+func (explicitTestController) List(c *gin.Context) {
+	c.String(http.StatusOK, "explicit list")
+}
+
+// helper method which matches the naming convention
+func (explicitTestController) GetDB() string {
+	return "db"
+}
+
+func TestConventionRouter(t *testing.T) {
+	setupTest(t, nil)
+	UseConventionController(conventionTestController{})
+	UseConventionController(&explicitTestController{})
+	handler := newTestHandler(t)
+
+	tests := []struct {
+		path   string
+		status int
+		body   string
+	}{
+		{"/convention-test/list", http.StatusOK, "list"},
+		{"/convention-test/1", http.StatusOK, "user 1"},
+		{"/explicit/list", http.StatusOK, "explicit list"},
+		{"/explicit/db", http.StatusNotFound, ""},
+	}
+	for _, test := range tests {
+		w := doRequest(handler, testRequest{target: test.path})
+		if w.Code != test.status || (test.body != "" && w.Body.String() != test.body) {
+			t.Errorf("%s: got %d %q, want %d %q", test.path, w.Code, w.Body.String(), test.status, test.body)
+		}
+	}
+
+	for _, route := range Routes() {
+		if want := route.Name; route.Handlers[len(route.Handlers)-1] != want {
+			t.Errorf("%s: got handlers %v, want %s", route.Path, route.Handlers, want)
+		}
+	}
+	// names are qualified by package
+	if url, err := URLFor("gs.conventionTestController.GetByID", 1); err != nil || url != "/convention-test/1" {
+		t.Errorf("got %q, %v", url, err)
+	}
+	if url, err := URLFor("gs.explicitTestController.List"); err != nil || url != "/explicit/list" {
+		t.Errorf("got %q, %v", url, err)
+	}
+}
+
+func TestParseConventionMethod(t *testing.T) {
+	tests := []struct {
+		name   string
+		method HttpMethod
+		path   string
+		ok     bool
+	}{
+		{"Get", GET, "", true},
+		{"GetOrderItemsByID", GET, "/order-items/:id", true},
+		{"DeleteByUserIdByName", DELETE, "/:userId/:name", true},
+		{"Getter", 0, "", false},
+		{"List", 0, "", false},
+	}
+	for _, test := range tests {
+		method, path, ok := parseConventionMethod(test.name)
+		if method != test.method || path != test.path || ok != test.ok {
+			t.Errorf("%s: got %v %q %v", test.name, method, path, ok)
+		}
+	}
+}
+
+type unknownRouteTestController struct{}
+
+func (unknownRouteTestController) RouteSpec() RouteSpec {
+	return RouteSpec{Routes: map[string]string{"List": "GET /list", "Lsit": "GET /list"}}
+}
+
+func (unknownRouteTestController) List(c *gin.Context) {}
+
+func TestConventionRouterUnknownRoute(t *testing.T) {
+	defer func() {
+		want := `gs.unknownRouteTestController.Lsit: no exported method for route spec "GET /list"`
+		if r := recover(); r != want {
+			t.Errorf("got panic %v, want %s", r, want)
+		}
+	}()
+	NewConventionRouter(unknownRouteTestController{})
+}
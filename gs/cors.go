@@ -0,0 +1,237 @@
+package gs
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/dan-kuroto/gin-stronger/config"
+	"github.com/gin-gonic/gin"
+)
+
+var defaultCORSMethods = []string{
+	http.MethodGet, http.MethodHead, http.MethodPost, http.MethodPut, http.MethodPatch, http.MethodDelete,
+}
+
+// compiled config.CORSPolicy
+type corsPolicy struct {
+	anyOrigin        bool
+	origins          []string
+	originPatterns   []*regexp.Regexp
+	methods          []string
+	allowHeaders     []string
+	exposeHeaders    string
+	allowCredentials bool
+	maxAge           string
+}
+
+// key of `routeKey` => policy of routers with `Router.CORS`
+var corsOverrides = make(map[string]*corsPolicy)
+
+func newCORSPolicy(cfg *config.CORSPolicy) (*corsPolicy, error) {
+	if cfg.AllowCredentials && slices.Contains(cfg.AllowOrigins, "*") {
+		// any site could read responses with credentials of users
+		return nil, errors.New("cors: allow-credentials can't be used with allow-origins *")
+	}
+	policy := &corsPolicy{
+		methods:          cfg.AllowMethods,
+		exposeHeaders:    strings.Join(cfg.ExposeHeaders, ", "),
+		allowCredentials: cfg.AllowCredentials,
+	}
+	for _, origin := range cfg.AllowOrigins {
+		if origin == "*" {
+			policy.anyOrigin = true
+		} else if expr, ok := strings.CutPrefix(origin, "regex:"); ok {
+			pattern, err := regexp.Compile(expr)
+			if err != nil {
+				return nil, fmt.Errorf("cors: invalid origin %q: %w", origin, err)
+			}
+			policy.originPatterns = append(policy.originPatterns, pattern)
+		} else if strings.Contains(origin, "*") {
+			// always valid since the rest is quoted
+			expr := strings.ReplaceAll(regexp.QuoteMeta(strings.ToLower(origin)), `\*`, `[^/]+`)
+			policy.originPatterns = append(policy.originPatterns, regexp.MustCompile("^"+expr+"$"))
+		} else {
+			policy.origins = append(policy.origins, strings.ToLower(origin))
+		}
+	}
+	if len(policy.methods) == 0 {
+		policy.methods = defaultCORSMethods
+	}
+	for _, header := range cfg.AllowHeaders {
+		policy.allowHeaders = append(policy.allowHeaders, http.CanonicalHeaderKey(header))
+	}
+	if cfg.MaxAge > 0 {
+		policy.maxAge = strconv.Itoa(int(cfg.MaxAge.Seconds()))
+	}
+	return policy, nil
+}
+
+func (policy *corsPolicy) allowOrigin(origin string) bool {
+	if policy.anyOrigin {
+		return true
+	}
+	lowerOrigin := strings.ToLower(origin)
+	if slices.Contains(policy.origins, lowerOrigin) {
+		return true
+	}
+	for _, pattern := range policy.originPatterns {
+		if pattern.MatchString(lowerOrigin) {
+			return true
+		}
+	}
+	return false
+}
+
+// Return false if any of requested headers is not allowed.
+func (policy *corsPolicy) allowRequestHeaders(requestHeaders string) bool {
+	if len(policy.allowHeaders) == 0 {
+		return true
+	}
+	for _, header := range strings.Split(requestHeaders, ",") {
+		header = strings.TrimSpace(header)
+		if header != "" && !slices.Contains(policy.allowHeaders, http.CanonicalHeaderKey(header)) {
+			return false
+		}
+	}
+	return true
+}
+
+// policy of `cors` config, it is nil if disabled
+func getGlobalCORSPolicy() (*corsPolicy, error) {
+	cfg := getCORSConfig()
+	if !cfg.Enable {
+		return nil, nil
+	}
+	return newCORSPolicy(&cfg.CORSPolicy)
+}
+
+// Handle CORS of all routes by `cors` config and `Router.CORS`. Preflight requests are answered
+// with 204, and rejected with 403 if origin, method or headers is not allowed.
+func corsMiddleware() gin.HandlerFunc {
+	globalPolicy, err := getGlobalCORSPolicy()
+	if err != nil {
+		panic(err)
+	}
+
+	return func(c *gin.Context) {
+		origin := c.GetHeader("Origin")
+		requestMethod := c.GetHeader("Access-Control-Request-Method")
+		preflight := c.Request.Method == http.MethodOptions && requestMethod != ""
+		if !preflight {
+			requestMethod = c.Request.Method
+		}
+		policy, ok := corsOverrides[routeKey(getRequestHostPattern(c), requestMethod, c.FullPath())]
+		if !ok {
+			policy = globalPolicy
+		}
+		if origin == "" || policy == nil {
+			return
+		}
+
+		header := c.Writer.Header()
+		header.Add("Vary", "Origin")
+		if preflight {
+			header.Add("Vary", "Access-Control-Request-Method")
+			header.Add("Vary", "Access-Control-Request-Headers")
+		}
+		if !policy.allowOrigin(origin) {
+			if preflight {
+				AbortWithError(c, http.StatusForbidden, "origin is not allowed")
+			}
+			return
+		}
+		if preflight && !slices.Contains(policy.methods, strings.ToUpper(requestMethod)) {
+			AbortWithError(c, http.StatusForbidden, "method is not allowed")
+			return
+		}
+		if preflight && !policy.allowRequestHeaders(c.GetHeader("Access-Control-Request-Headers")) {
+			AbortWithError(c, http.StatusForbidden, "headers are not allowed")
+			return
+		}
+
+		if policy.anyOrigin {
+			header.Set("Access-Control-Allow-Origin", "*")
+		} else {
+			header.Set("Access-Control-Allow-Origin", origin)
+		}
+		if policy.allowCredentials {
+			header.Set("Access-Control-Allow-Credentials", "true")
+		}
+		if !preflight {
+			if policy.exposeHeaders != "" {
+				header.Set("Access-Control-Expose-Headers", policy.exposeHeaders)
+			}
+			return
+		}
+
+		requestHeaders := c.GetHeader("Access-Control-Request-Headers")
+		header.Set("Access-Control-Allow-Methods", strings.Join(policy.methods, ", "))
+		if len(policy.allowHeaders) != 0 {
+			header.Set("Access-Control-Allow-Headers", strings.Join(policy.allowHeaders, ", "))
+		} else if requestHeaders != "" {
+			header.Set("Access-Control-Allow-Headers", requestHeaders)
+		}
+		if policy.maxAge != "" {
+			header.Set("Access-Control-Max-Age", policy.maxAge)
+		}
+		c.AbortWithStatus(http.StatusNoContent)
+	}
+}
+
+// Routers are registered by gin only for their own methods, so OPTIONS routes are added
+// for routers with `Router.CORS`, otherwise their preflight requests can't be matched.
+type corsPreflights struct {
+	// keys of OPTIONS routes, names of wildcards are removed since they must be the same
+	registered map[string]bool
+	pending    []corsPreflight
+}
+
+type corsPreflight struct {
+	router       ginEngineOrGroup
+	relativePath string
+	host         *hostPattern
+	fullPath     string
+}
+
+func (preflights *corsPreflights) add(router ginEngineOrGroup, gsRouter *Router, host *hostPattern, policy *corsPolicy) {
+	fullPath := joinPaths(router.BasePath(), gsRouter.Path)
+	for _, method := range gsRouter.Method.names() {
+		corsOverrides[routeKey(host, method, fullPath)] = policy
+	}
+	corsOverrides[routeKey(host, http.MethodOptions, fullPath)] = policy
+	preflights.pending = append(preflights.pending, corsPreflight{router, gsRouter.Path, host, fullPath})
+}
+
+// Called after all routers are registered, paths which already have OPTIONS route are skipped.
+func (preflights *corsPreflights) register() {
+	for _, preflight := range preflights.pending {
+		if preflights.registered[routeKey(preflight.host, http.MethodOptions, wildcardFreePath(preflight.fullPath))] {
+			continue
+		}
+		preflights.markRegistered(preflight.host, preflight.fullPath)
+		// it is reached only if it's not a preflight request, which is answered by corsMiddleware
+		preflight.router.OPTIONS(preflight.relativePath, func(c *gin.Context) {
+			c.Status(http.StatusNoContent)
+		})
+	}
+}
+
+func (preflights *corsPreflights) markRegistered(host *hostPattern, fullPath string) {
+	preflights.registered[routeKey(host, http.MethodOptions, wildcardFreePath(fullPath))] = true
+}
+
+// e.g. /user/:id/*path => /user/:/*
+func wildcardFreePath(fullPath string) string {
+	segments := strings.Split(fullPath, "/")
+	for i, segment := range segments {
+		if prefix, wildcard := splitWildcard(segment); wildcard != "" {
+			segments[i] = prefix + wildcard[:1]
+		}
+	}
+	return strings.Join(segments, "/")
+}
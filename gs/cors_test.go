@@ -0,0 +1,83 @@
+package gs
+
+import (
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/dan-kuroto/gin-stronger/config"
+	"github.com/gin-gonic/gin"
+)
+
+func TestCORS(t *testing.T) {
+	setupTest(t, func(cfg *config.Configuration) {
+		cfg.CORS.Enable = true
+		cfg.CORS.AllowOrigins = []string{"https://app.example.com", "https://*.example.org"}
+		cfg.CORS.AllowHeaders = []string{"content-type"}
+		cfg.CORS.MaxAge = 10 * time.Minute
+	})
+	rootRouter.Children = []Router{
+		{Path: "/users", Method: GET | POST, Handlers: []gin.HandlerFunc{stringHandler("users")}},
+		{Path: "/public", Method: GET, CORS: &config.CORSPolicy{AllowOrigins: []string{"*"}},
+			Handlers: []gin.HandlerFunc{stringHandler("public")}},
+	}
+	handler := newTestHandler(t)
+
+	tests := []struct {
+		name    string
+		request testRequest
+		status  int
+		origin  string
+	}{
+		{"simple", testRequest{target: "/users", header: map[string]string{"Origin": "https://app.example.com"}},
+			http.StatusOK, "https://app.example.com"},
+		{"wildcard subdomain", testRequest{target: "/users", header: map[string]string{"Origin": "https://a.example.org"}},
+			http.StatusOK, "https://a.example.org"},
+		{"disallowed origin", testRequest{target: "/users", header: map[string]string{"Origin": "https://evil.com"}},
+			http.StatusOK, ""},
+		{"preflight", testRequest{method: http.MethodOptions, target: "/users", header: map[string]string{
+			"Origin": "https://app.example.com", "Access-Control-Request-Method": "POST", "Access-Control-Request-Headers": "Content-Type",
+		}}, http.StatusNoContent, "https://app.example.com"},
+		{"preflight of disallowed origin", testRequest{method: http.MethodOptions, target: "/users", header: map[string]string{
+			"Origin": "https://evil.com", "Access-Control-Request-Method": "POST",
+		}}, http.StatusForbidden, ""},
+		{"preflight of disallowed header", testRequest{method: http.MethodOptions, target: "/users", header: map[string]string{
+			"Origin": "https://app.example.com", "Access-Control-Request-Method": "POST", "Access-Control-Request-Headers": "X-Secret",
+		}}, http.StatusForbidden, ""},
+		{"router policy", testRequest{target: "/public", header: map[string]string{"Origin": "https://evil.com"}},
+			http.StatusOK, "*"},
+		{"preflight of router policy", testRequest{method: http.MethodOptions, target: "/public", header: map[string]string{
+			"Origin": "https://evil.com", "Access-Control-Request-Method": "GET",
+		}}, http.StatusNoContent, "*"},
+	}
+	for _, test := range tests {
+		w := doRequest(handler, test.request)
+		if w.Code != test.status || w.Header().Get("Access-Control-Allow-Origin") != test.origin {
+			t.Errorf("%s: got %d origin %q, want %d %q", test.name, w.Code, w.Header().Get("Access-Control-Allow-Origin"), test.status, test.origin)
+		}
+	}
+	w := doRequest(handler, tests[3].request)
+	if w.Header().Get("Access-Control-Max-Age") != "600" || w.Header().Get("Access-Control-Allow-Headers") != "Content-Type" {
+		t.Errorf("preflight: got headers %v", w.Header())
+	}
+}
+
+func TestCORSRejectsCredentialsWithAnyOrigin(t *testing.T) {
+	policy := &config.CORSPolicy{AllowOrigins: []string{"*"}, AllowCredentials: true}
+	if _, err := newCORSPolicy(policy); err == nil {
+		t.Error("credentials with any origin is not rejected")
+	}
+	router := Router{Path: "/", Method: GET, CORS: policy, Handlers: []gin.HandlerFunc{stringHandler("")}}
+	if err := ValidateRouter(&router); err == nil {
+		t.Error("credentials with any origin is not reported by validation")
+	}
+}
+
+func TestCORSInvalidOriginRegex(t *testing.T) {
+	router := Router{Path: "/", Method: GET, CORS: &config.CORSPolicy{AllowOrigins: []string{"regex:^https://(a|b$"}},
+		Handlers: []gin.HandlerFunc{stringHandler("")}}
+	err := ValidateRouter(&router)
+	if errs, ok := err.(RouterErrors); !ok || len(errs) != 1 {
+		t.Errorf("got %v, want invalid origin reported", err)
+	}
+}
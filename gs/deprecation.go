@@ -0,0 +1,71 @@
+package gs
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// deprecation settings inherited from parent router groups
+type deprecation struct {
+	deprecated   bool
+	deprecatedAt time.Time
+	sunset       time.Time
+	link         string
+	gone         bool
+}
+
+func (d deprecation) inherit(gsRouter *Router) deprecation {
+	if gsRouter.Deprecated {
+		d.deprecated = true
+	}
+	if !gsRouter.DeprecatedAt.IsZero() {
+		d.deprecated = true
+		d.deprecatedAt = gsRouter.DeprecatedAt
+	}
+	if !gsRouter.Sunset.IsZero() {
+		d.sunset = gsRouter.Sunset
+	}
+	if gsRouter.DeprecationLink != "" {
+		d.link = gsRouter.DeprecationLink
+	}
+	if gsRouter.GoneAfterSunset {
+		d.gone = true
+	}
+	return d
+}
+
+func (d deprecation) enabled() bool {
+	return d.deprecated || !d.sunset.IsZero()
+}
+
+// Add Deprecation, Sunset and Link headers, and log each call to the deprecated route.
+// If `gone` is true, respond 410 after the sunset date.
+func deprecationMiddleware(d deprecation) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		header := c.Writer.Header()
+		if !d.deprecatedAt.IsZero() {
+			header.Set("Deprecation", "@"+strconv.FormatInt(d.deprecatedAt.Unix(), 10))
+		}
+		if !d.sunset.IsZero() {
+			header.Set("Sunset", d.sunset.UTC().Format(http.TimeFormat))
+		}
+		if d.link != "" {
+			header.Add("Link", "<"+d.link+`>; rel="deprecation"`)
+		}
+
+		logger := GetLoggerByGinCtx(c)
+		if !d.sunset.IsZero() && d.gone && time.Now().After(d.sunset) {
+			logger.Warn().Str("path", c.FullPath()).Time("sunset", d.sunset).Msg("call to sunset route")
+			AbortWithError(c, http.StatusGone, "this api is no longer available since "+d.sunset.UTC().Format(http.TimeFormat))
+			return
+		}
+		event := logger.Warn().Str("path", c.FullPath())
+		if !d.sunset.IsZero() {
+			event = event.Time("sunset", d.sunset)
+		}
+		event.Msg("call to deprecated route")
+	}
+}
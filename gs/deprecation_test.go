@@ -0,0 +1,52 @@
+package gs
+
+import (
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestDeprecation(t *testing.T) {
+	setupTest(t, nil)
+	future := time.Now().Add(24 * time.Hour).Truncate(time.Second)
+	past := time.Now().Add(-24 * time.Hour)
+	deprecatedAt := time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC)
+	rootRouter.Children = []Router{
+		{Path: "/v1", DeprecatedAt: deprecatedAt, DeprecationLink: "https://example.com/migrate", Children: []Router{
+			{Path: "/user", Method: GET, Sunset: future, Handlers: []gin.HandlerFunc{stringHandler("user")}},
+		}},
+		{Path: "/old", Method: GET, Sunset: past, GoneAfterSunset: true, Handlers: []gin.HandlerFunc{stringHandler("old")}},
+		{Path: "/undated", Method: GET, Deprecated: true, Handlers: []gin.HandlerFunc{stringHandler("undated")}},
+		{Path: "/current", Method: GET, Handlers: []gin.HandlerFunc{stringHandler("current")}},
+	}
+	handler := newTestHandler(t)
+
+	w := doRequest(handler, testRequest{target: "/v1/user"})
+	if w.Code != http.StatusOK || w.Body.String() != "user" {
+		t.Errorf("deprecated route: got %d %q", w.Code, w.Body.String())
+	}
+	if got, want := w.Header().Get("Deprecation"), "@1767323045"; got != want {
+		t.Errorf("got Deprecation %q, want %q", got, want)
+	}
+	if got, err := http.ParseTime(w.Header().Get("Sunset")); err != nil || !got.Equal(future) {
+		t.Errorf("got Sunset %q", w.Header().Get("Sunset"))
+	}
+	if got := w.Header().Get("Link"); got != `<https://example.com/migrate>; rel="deprecation"` {
+		t.Errorf("got Link %q", got)
+	}
+
+	if w = doRequest(handler, testRequest{target: "/old"}); w.Code != http.StatusGone {
+		t.Errorf("sunset route: got %d, want 410", w.Code)
+	}
+	// the date is required by Deprecation header
+	w = doRequest(handler, testRequest{target: "/undated"})
+	if w.Code != http.StatusOK || w.Header().Get("Deprecation") != "" {
+		t.Errorf("undated route: got %d %v", w.Code, w.Header())
+	}
+	w = doRequest(handler, testRequest{target: "/current"})
+	if w.Header().Get("Deprecation") != "" || w.Header().Get("Sunset") != "" {
+		t.Errorf("current route: got headers %v", w.Header())
+	}
+}
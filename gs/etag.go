@@ -0,0 +1,98 @@
+package gs
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Result types of packaged handlers can implement it to supply ETag, so that the result is not hashed.
+// Version is used as strong ETag, it is quoted if it's not quoted yet. Empty version is ignored.
+type ResultVersion interface {
+	Version() string
+}
+
+// Result types of packaged handlers can implement it to supply Last-Modified for If-Modified-Since.
+// Zero time is ignored.
+type ResultLastModified interface {
+	LastModified() time.Time
+}
+
+const conditionalKey = "gs.conditional"
+
+// Mark GET and HEAD requests, whose results of packaged handlers are sent by `writeConditionalJSON`.
+func conditionalMiddleware(c *gin.Context) {
+	if c.Request.Method == http.MethodGet || c.Request.Method == http.MethodHead {
+		c.Set(conditionalKey, true)
+	}
+}
+
+// Send result as JSON with ETag and Last-Modified, or 304 if it's not modified since the client's copy.
+// ETag is computed from serialized result unless it implements `ResultVersion`.
+func writeConditionalJSON(c *gin.Context, result any) {
+	var etag string
+	var body []byte
+	if versioned, ok := result.(ResultVersion); ok {
+		etag = quoteETag(versioned.Version())
+	}
+	if etag == "" {
+		var err error
+		if body, err = json.Marshal(result); err != nil {
+			panic(err)
+		}
+		hash := sha256.Sum256(body)
+		etag = `"` + hex.EncodeToString(hash[:16]) + `"`
+	}
+	var lastModified time.Time
+	if modified, ok := result.(ResultLastModified); ok {
+		lastModified = modified.LastModified()
+	}
+
+	c.Header("ETag", etag)
+	if !lastModified.IsZero() {
+		c.Header("Last-Modified", lastModified.UTC().Format(http.TimeFormat))
+	}
+	if isNotModified(c.Request, etag, lastModified) {
+		c.Status(http.StatusNotModified)
+		return
+	}
+	if body == nil {
+		c.JSON(http.StatusOK, result)
+	} else {
+		c.Data(http.StatusOK, "application/json; charset=utf-8", body)
+	}
+}
+
+func quoteETag(version string) string {
+	if version == "" || strings.HasPrefix(version, `"`) || strings.HasPrefix(version, `W/"`) {
+		return version
+	}
+	return `"` + version + `"`
+}
+
+// If-Modified-Since is ignored if If-None-Match is present (RFC 9110 13.1.3).
+func isNotModified(request *http.Request, etag string, lastModified time.Time) bool {
+	if ifNoneMatch := request.Header.Get("If-None-Match"); ifNoneMatch != "" {
+		for _, candidate := range strings.Split(ifNoneMatch, ",") {
+			candidate = strings.TrimSpace(candidate)
+			// weak comparison
+			if candidate == "*" || strings.TrimPrefix(candidate, "W/") == strings.TrimPrefix(etag, "W/") {
+				return true
+			}
+		}
+		return false
+	}
+	if lastModified.IsZero() {
+		return false
+	}
+	since, err := http.ParseTime(request.Header.Get("If-Modified-Since"))
+	if err != nil {
+		return false
+	}
+	return !lastModified.Truncate(time.Second).After(since)
+}
@@ -0,0 +1,108 @@
+package gs
+
+import (
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/dan-kuroto/gin-stronger/config"
+)
+
+type etagTestItem struct {
+	Name string `json:"name"`
+}
+
+type versionedTestItem struct {
+	Name     string `json:"name"`
+	version  string
+	modified time.Time
+}
+
+func (item versionedTestItem) Version() string {
+	return item.version
+}
+
+func (item versionedTestItem) LastModified() time.Time {
+	return item.modified
+}
+
+func TestConditionalGet(t *testing.T) {
+	setupTest(t, nil)
+	modified := time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC)
+	rootRouter.Children = []Router{{Path: "/item", ETag: true, Children: []Router{
+		{Path: "", Method: GET | POST, Handlers: PackageHandlers(func() etagTestItem {
+			return etagTestItem{Name: "item"}
+		})},
+		// default method is GET
+		{Path: "/default", Handlers: PackageHandlers(func() etagTestItem {
+			return etagTestItem{Name: "item"}
+		})},
+		{Path: "/versioned", Method: GET, Handlers: PackageHandlers(func() versionedTestItem {
+			return versionedTestItem{Name: "item", version: "v1", modified: modified}
+		})},
+	}}}
+	handler := newTestHandler(t)
+
+	w := doRequest(handler, testRequest{target: "/item"})
+	etag := w.Header().Get("ETag")
+	if w.Code != http.StatusOK || w.Body.String() != `{"name":"item"}` || etag == "" {
+		t.Fatalf("got %d %q, ETag %q", w.Code, w.Body.String(), etag)
+	}
+	if w = doRequest(handler, testRequest{target: "/item", header: map[string]string{"If-None-Match": "W/" + etag}}); w.Code != http.StatusNotModified || w.Body.Len() != 0 {
+		t.Errorf("If-None-Match: got %d %q, want 304", w.Code, w.Body.String())
+	}
+	if w = doRequest(handler, testRequest{target: "/item", header: map[string]string{"If-None-Match": `"other"`}}); w.Code != http.StatusOK {
+		t.Errorf("other ETag: got %d, want 200", w.Code)
+	}
+	if w = doRequest(handler, testRequest{target: "/item/default", header: map[string]string{"If-None-Match": etag}}); w.Code != http.StatusNotModified {
+		t.Errorf("default method: got %d, want 304", w.Code)
+	}
+	// unsafe methods are not conditional
+	w = doRequest(handler, testRequest{method: http.MethodPost, target: "/item", header: map[string]string{"If-None-Match": etag}})
+	if w.Code != http.StatusOK || w.Header().Get("ETag") != "" {
+		t.Errorf("POST: got %d, ETag %q", w.Code, w.Header().Get("ETag"))
+	}
+
+	w = doRequest(handler, testRequest{target: "/item/versioned"})
+	if w.Header().Get("ETag") != `"v1"` || w.Header().Get("Last-Modified") != modified.Format(http.TimeFormat) {
+		t.Errorf("versioned: got headers %v", w.Header())
+	}
+	tests := []struct {
+		header map[string]string
+		status int
+	}{
+		{map[string]string{"If-Modified-Since": modified.Format(http.TimeFormat)}, http.StatusNotModified},
+		{map[string]string{"If-Modified-Since": modified.Add(-time.Second).Format(http.TimeFormat)}, http.StatusOK},
+		// If-Modified-Since is ignored if If-None-Match is present
+		{map[string]string{"If-None-Match": `"v0"`, "If-Modified-Since": modified.Format(http.TimeFormat)}, http.StatusOK},
+		{map[string]string{"If-None-Match": `"v0", "v1"`}, http.StatusNotModified},
+	}
+	for _, test := range tests {
+		if w = doRequest(handler, testRequest{target: "/item/versioned", header: test.header}); w.Code != test.status {
+			t.Errorf("%v: got %d, want %d", test.header, w.Code, test.status)
+		}
+	}
+}
+
+func TestConditionalGetWithCompression(t *testing.T) {
+	setupTest(t, func(cfg *config.Configuration) {
+		cfg.Compression.Enable = true
+		cfg.Compression.MinSize = 1
+	})
+	rootRouter.Children = []Router{{Path: "/item", Method: GET, ETag: true, Handlers: PackageHandlers(func() etagTestItem {
+		return etagTestItem{Name: "item"}
+	})}}
+	handler := newTestHandler(t)
+
+	w := doRequest(handler, testRequest{target: "/item", header: map[string]string{"Accept-Encoding": "gzip"}})
+	etag := w.Header().Get("ETag")
+	if w.Header().Get("Content-Encoding") != "gzip" || !strings.HasPrefix(etag, "W/") {
+		t.Fatalf("got Content-Encoding %q, ETag %q", w.Header().Get("Content-Encoding"), etag)
+	}
+	// weak ETag of compressed response matches
+	w = doRequest(handler, testRequest{target: "/item", header: map[string]string{"Accept-Encoding": "gzip", "If-None-Match": etag}})
+	if w.Code != http.StatusNotModified || w.Body.Len() != 0 {
+		t.Errorf("got %d %q, want 304", w.Code, w.Body.String())
+	}
+}
@@ -0,0 +1,105 @@
+package gs
+
+import (
+	"net/http"
+	"slices"
+	"sync"
+
+	"github.com/gin-gonic/gin"
+)
+
+var featureMutex sync.RWMutex
+
+// feature key => enabled, set at runtime and take precedence over config
+var featureOverrides = make(map[string]bool)
+
+// Enable or disable feature at runtime, it takes effect immediately and overrides `features` config.
+func SetFeature(key string, enabled bool) {
+	featureMutex.Lock()
+	defer featureMutex.Unlock()
+	featureOverrides[key] = enabled
+}
+
+// Remove runtime override of feature set by `SetFeature`, so that config takes effect again.
+func ResetFeature(key string) {
+	featureMutex.Lock()
+	defer featureMutex.Unlock()
+	delete(featureOverrides, key)
+}
+
+func IsFeatureEnabled(key string) bool {
+	featureMutex.RLock()
+	enabled, ok := featureOverrides[key]
+	featureMutex.RUnlock()
+	if ok {
+		return enabled
+	}
+
+	toggle, ok := getFeaturesConfig().Toggles[key]
+	if !ok {
+		return true
+	}
+	if toggle.Disable {
+		return false
+	}
+	return len(toggle.Envs) == 0 || slices.Contains(toggle.Envs, Config.GetActiveEnv())
+}
+
+// Get states of features which are used by routers, configured or set at runtime.
+func Features() map[string]bool {
+	keys := make(map[string]struct{})
+	for _, route := range routes {
+		for _, key := range route.Features {
+			keys[key] = struct{}{}
+		}
+	}
+	for key := range getFeaturesConfig().Toggles {
+		keys[key] = struct{}{}
+	}
+	featureMutex.RLock()
+	for key := range featureOverrides {
+		keys[key] = struct{}{}
+	}
+	featureMutex.RUnlock()
+
+	result := make(map[string]bool, len(keys))
+	for key := range keys {
+		result[key] = IsFeatureEnabled(key)
+	}
+	return result
+}
+
+// Respond `features.disabled-status` if any of the features is disabled.
+func featureMiddleware(keys []string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		for _, key := range keys {
+			if !IsFeatureEnabled(key) {
+				status := getFeaturesConfig().DisabledStatus
+				AbortWithError(c, status, http.StatusText(status))
+				return
+			}
+		}
+	}
+}
+
+func adminFeatures(c *gin.Context) {
+	c.JSON(http.StatusOK, Features())
+}
+
+// Body is {"enabled": true} or {"enabled": false}, and {"enabled": null} resets to config.
+func adminSetFeature(c *gin.Context) {
+	var body struct {
+		Enabled *bool `json:"enabled"`
+	}
+	if err := c.ShouldBindJSON(&body); err != nil {
+		AbortWithError(c, http.StatusBadRequest, err.Error())
+		return
+	}
+	key := c.Param("key")
+	if body.Enabled == nil {
+		ResetFeature(key)
+	} else {
+		SetFeature(key, *body.Enabled)
+	}
+	c.JSON(http.StatusOK, gin.H{key: IsFeatureEnabled(key)})
+}
@@ -0,0 +1,59 @@
+package gs
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/dan-kuroto/gin-stronger/config"
+	"github.com/gin-gonic/gin"
+)
+
+func TestFeatureToggle(t *testing.T) {
+	setupTest(t, func(cfg *config.Configuration) {
+		cfg.Env.Active = "prod"
+		cfg.Features.DisabledStatus = http.StatusServiceUnavailable
+		cfg.Features.Toggles = map[string]config.FeatureToggle{
+			"beta":   {Envs: []string{"dev"}},
+			"legacy": {Disable: true},
+		}
+	})
+	rootRouter.Children = []Router{
+		{Path: "/beta", Feature: "beta", Children: []Router{
+			{Path: "/new", Method: GET, Handlers: []gin.HandlerFunc{stringHandler("new")}},
+		}},
+		{Path: "/legacy", Method: GET, Feature: "legacy", Handlers: []gin.HandlerFunc{stringHandler("legacy")}},
+		{Path: "/other", Method: GET, Feature: "other", Handlers: []gin.HandlerFunc{stringHandler("other")}},
+	}
+	handler := newTestHandler(t)
+	expect := func(path string, status int) {
+		t.Helper()
+		if w := doRequest(handler, testRequest{target: path}); w.Code != status {
+			t.Errorf("%s: got %d, want %d", path, w.Code, status)
+		}
+	}
+
+	// disabled by env, inherited by children
+	expect("/beta/new", http.StatusServiceUnavailable)
+	expect("/legacy", http.StatusServiceUnavailable)
+	// features which are not configured are enabled
+	expect("/other", http.StatusOK)
+
+	SetFeature("beta", true)
+	SetFeature("other", false)
+	expect("/beta/new", http.StatusOK)
+	expect("/other", http.StatusServiceUnavailable)
+
+	ResetFeature("beta")
+	expect("/beta/new", http.StatusServiceUnavailable)
+
+	want := map[string]bool{"beta": false, "legacy": false, "other": false}
+	for key, enabled := range Features() {
+		if want[key] != enabled {
+			t.Errorf("feature %s: got %v", key, enabled)
+		}
+		delete(want, key)
+	}
+	if len(want) != 0 {
+		t.Errorf("missing features %v", want)
+	}
+}
@@ -0,0 +1,99 @@
+package gs
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/dan-kuroto/gin-stronger/config"
+	"github.com/gin-gonic/gin"
+)
+
+func init() {
+	gin.SetMode(gin.TestMode)
+	gin.DefaultWriter = io.Discard
+}
+
+// Reset global state of gs, and use default config modified by `modify`.
+func setupTest(t *testing.T, modify func(cfg *config.Configuration)) {
+	t.Helper()
+	cfg := &config.Configuration{}
+	if modify != nil {
+		modify(cfg)
+	}
+	cfg.SolveDefaultValue()
+	Config = cfg
+
+	rootRouter = Router{Path: ""}
+	adminRootRouter = Router{Path: "", admin: true}
+	staticMapFunc = nil
+	staticMounts = make([]StaticMount, 0)
+	noRouteHandlers = make([]gin.HandlerFunc, 0)
+	hostPatterns = make([]*hostPattern, 0)
+	hostEngines = make(map[*gin.Engine]map[*hostPattern]*gin.Engine)
+	versionedControllerList = make([]*versionedControllers, 0)
+	corsOverrides = make(map[string]*corsPolicy)
+	featureOverrides = make(map[string]bool)
+	routes = make([]RouteInfo, 0)
+	namedRoutes = make(map[string]int)
+	rateLimitStore, rateLimitStoreOnce = nil, sync.Once{}
+	defaultCompressor, defaultCompressorOnce = nil, sync.Once{}
+	idempotencyStore = NewMemoryIdempotencyStore()
+	hooks = make([]Hook, 0)
+}
+
+// Build handler of public listener like `RunApp`, routers should be registered before it.
+func newTestHandler(t *testing.T) http.Handler {
+	t.Helper()
+	if len(rootRouter.Children) != 0 {
+		if err := ValidateRouter(&rootRouter); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return newPublicHandler(newEngine())
+}
+
+type testRequest struct {
+	method string
+	target string
+	body   string
+	header map[string]string
+}
+
+func doRequest(handler http.Handler, request testRequest) *httptest.ResponseRecorder {
+	return doRequestWith(handler, request, nil)
+}
+
+// same as doRequest, and the request can be modified before it's served
+func doRequestWith(handler http.Handler, request testRequest, modify func(req *http.Request)) *httptest.ResponseRecorder {
+	if request.method == "" {
+		request.method = http.MethodGet
+	}
+	var body io.Reader
+	if request.body != "" {
+		body = strings.NewReader(request.body)
+	}
+	req := httptest.NewRequest(request.method, request.target, body)
+	for name, value := range request.header {
+		if name == "Host" {
+			req.Host = value
+		} else {
+			req.Header.Set(name, value)
+		}
+	}
+	if modify != nil {
+		modify(req)
+	}
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, req)
+	return w
+}
+
+func stringHandler(text string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		c.String(http.StatusOK, text)
+	}
+}
@@ -1,13 +1,46 @@
 package gs
 
 import (
+	"context"
+	"errors"
 	"net/http"
 	"reflect"
+	"runtime"
+	"strings"
 
 	"github.com/gin-gonic/gin"
 )
 
 var ginContextType = reflect.TypeOf(&gin.Context{})
+var contextType = reflect.TypeOf((*context.Context)(nil)).Elem()
+
+// information of the function packaged by `PackageHandlers`
+type handlerInfo struct {
+	// name of the function, e.g. controller.GetUser
+	name        string
+	paramTypes  []reflect.Type
+	resultTypes []reflect.Type
+}
+
+// It returns error if the function is not supported by `PackageHandlers`.
+func newHandlerInfo(function any) (*handlerInfo, error) {
+	funcType := reflect.TypeOf(function)
+	if funcType == nil || funcType.Kind() != reflect.Func {
+		return nil, errors.New("handler must be a function")
+	}
+	info := &handlerInfo{
+		name:        getFunctionName(function),
+		paramTypes:  getFunctionParamTypes(funcType),
+		resultTypes: getFunctionResultTypes(funcType),
+	}
+	if !isSupportedParamTypes(info.paramTypes) {
+		return nil, errors.New("function parameter type is not supported")
+	}
+	if len(info.resultTypes) > 1 {
+		return nil, errors.New("function result type is not supported")
+	}
+	return info, nil
+}
 
 func getFunctionParamTypes(funcType reflect.Type) []reflect.Type {
 	numIn := funcType.NumIn()
@@ -27,6 +60,32 @@ func getFunctionResultTypes(funcType reflect.Type) []reflect.Type {
 	return types
 }
 
+// e.g. github.com/foo/bar/controller.GetUser => controller.GetUser
+func getFunctionName(function any) string {
+	funcObj := runtime.FuncForPC(reflect.ValueOf(function).Pointer())
+	if funcObj == nil {
+		return ""
+	}
+	name := funcObj.Name()
+	return name[strings.LastIndex(name, "/")+1:]
+}
+
+// at most one *gin.Context, one context.Context and one request struct
+func isSupportedParamTypes(paramTypes []reflect.Type) bool {
+	var ginContexts, contexts, requests int
+	for _, paramType := range paramTypes {
+		switch paramType {
+		case ginContextType:
+			ginContexts++
+		case contextType:
+			contexts++
+		default:
+			requests++
+		}
+	}
+	return ginContexts <= 1 && contexts <= 1 && requests <= 1
+}
+
 func callFunction(funcValue reflect.Value, inputs ...reflect.Value) []any {
 	output := funcValue.Call(inputs)
 
@@ -43,6 +102,9 @@ func packageHandler(function any, paramTypes []reflect.Type, resultTypes []refle
 		for _, paramType := range paramTypes {
 			if paramType == ginContextType {
 				params = append(params, reflect.ValueOf(c))
+			} else if paramType == contextType {
+				// it has the deadline set by `Router.Timeout`
+				params = append(params, reflect.ValueOf(c.Request.Context()))
 			} else {
 				var param reflect.Value
 				if paramType.Kind() == reflect.Ptr {
@@ -51,6 +113,9 @@ func packageHandler(function any, paramTypes []reflect.Type, resultTypes []refle
 					param = reflect.New(paramType)
 				}
 				if err := c.ShouldBind(param.Interface()); err != nil {
+					if abortIfBodyTooLarge(c, err) {
+						return
+					}
 					panic(err)
 				}
 				if paramType.Kind() == reflect.Ptr {
@@ -61,39 +126,39 @@ func packageHandler(function any, paramTypes []reflect.Type, resultTypes []refle
 			}
 		}
 		results := callFunction(reflect.ValueOf(function), params...)
+		if errors.Is(c.Request.Context().Err(), context.DeadlineExceeded) {
+			// result may be incomplete since the handler was canceled, and it can't be
+			// replaced by error response if the handler has written something
+			if !c.Writer.Written() {
+				abortWithTimeout(c)
+			}
+			return
+		}
 		if len(results) == 1 {
-			c.JSON(http.StatusOK, results[0])
+			if c.GetBool(conditionalKey) {
+				writeConditionalJSON(c, results[0])
+			} else {
+				c.JSON(http.StatusOK, results[0])
+			}
 		}
 	}
 }
 
 // functions need to meet some conditions:
-// (1) Parameters can include *gin.Context and request struct.
+// (1) Parameters can include *gin.Context, context.Context and request struct.
 // (2) No result or return gs.IResponse
 func PackageHandlers(functions ...any) []gin.HandlerFunc {
 	handlers := make([]gin.HandlerFunc, 0, len(functions))
 	for _, function := range functions {
-		funcType := reflect.TypeOf(function)
-		paramTypes := getFunctionParamTypes(funcType)
-		resultTypes := getFunctionResultTypes(funcType)
-
-		if len(paramTypes) > 2 {
-			panic("function parameter type is not supported")
-		}
-		if len(resultTypes) > 1 {
-			panic("function result type is not supported")
-		}
-		if len(paramTypes) == 2 {
-			if (paramTypes[0] != ginContextType && paramTypes[1] != ginContextType) ||
-				(paramTypes[0] == ginContextType && paramTypes[1] == ginContextType) {
-				panic("function parameter type is not supported")
-			}
+		info, err := newHandlerInfo(function)
+		if err != nil {
+			panic(err.Error())
 		}
 		// if function is gin.HandlerFunc, packaging is unnecessary
-		if len(paramTypes) == 1 && len(resultTypes) == 0 && paramTypes[0] == ginContextType {
+		if len(info.paramTypes) == 1 && len(info.resultTypes) == 0 && info.paramTypes[0] == ginContextType {
 			handlers = append(handlers, gin.HandlerFunc(function.(func(*gin.Context))))
 		} else {
-			handlers = append(handlers, packageHandler(function, paramTypes, resultTypes))
+			handlers = append(handlers, packageHandler(function, info.paramTypes, info.resultTypes))
 		}
 	}
 	return handlers
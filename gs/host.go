@@ -0,0 +1,219 @@
+package gs
+
+import (
+	"fmt"
+	"net"
+	"net/http"
+	"slices"
+	"sort"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Routers bound to host are registered to a separate engine for each host pattern, and requests
+// are dispatched to it by `newHostHandler` according to Host header.
+
+// name of path param captured by "*" in host pattern
+const defaultHostParam = "subdomain"
+
+type hostPattern struct {
+	pattern string
+	// whole host if param is empty, otherwise the part after the first label, e.g. .example.com
+	suffix string
+	// name of path param which captures the first label, e.g. tenant of :tenant.example.com
+	param string
+}
+
+// registered host patterns, exact hosts first, then wildcard hosts with longer suffix
+var hostPatterns = make([]*hostPattern, 0)
+
+// gin engine => engines of routers bound to host, see `getHostEngine`
+var hostEngines = make(map[*gin.Engine]map[*hostPattern]*gin.Engine)
+
+// key of *hostPattern in gin.Context, set for requests dispatched to routers bound to host
+const hostPatternKey = "gs.host-pattern"
+
+// Register controller whose routers are served only for requests whose Host matches `host`.
+// See `Router.Host` for syntax of host pattern.
+func UseHostController(host string, controller Controller) {
+	router := controller.GetRouter()
+	if router.controller == "" {
+		router.controller = getTypeName(controller)
+	}
+	router.Host = host
+	rootRouter.Children = append(rootRouter.Children, router)
+}
+
+func parseHostPattern(pattern string) (*hostPattern, error) {
+	pattern = strings.ToLower(pattern)
+	first, suffix, _ := strings.Cut(pattern, ".")
+	if suffix == "" || strings.ContainsAny(suffix, ":*") {
+		return nil, fmt.Errorf("invalid host pattern %q, only the first label can be wildcard", pattern)
+	}
+	result := &hostPattern{pattern: pattern, suffix: pattern}
+	if first == "*" {
+		result.param = defaultHostParam
+	} else if strings.HasPrefix(first, ":") && len(first) > 1 {
+		result.param = first[1:]
+	} else if strings.ContainsAny(first, ":*") {
+		return nil, fmt.Errorf("invalid host pattern %q, wildcard must be * or :name", pattern)
+	}
+	if result.param != "" {
+		result.suffix = "." + suffix
+	}
+	return result, nil
+}
+
+// Get registered host pattern, it is registered if not found.
+func getHostPattern(pattern string) (*hostPattern, error) {
+	for _, item := range hostPatterns {
+		if strings.EqualFold(item.pattern, pattern) {
+			return item, nil
+		}
+	}
+	result, err := parseHostPattern(pattern)
+	if err != nil {
+		return nil, err
+	}
+	hostPatterns = append(hostPatterns, result)
+	sort.SliceStable(hostPatterns, func(i, j int) bool {
+		a, b := hostPatterns[i], hostPatterns[j]
+		if (a.param == "") != (b.param == "") {
+			return a.param == ""
+		}
+		return len(a.suffix) > len(b.suffix)
+	})
+	return result, nil
+}
+
+// Return captured label (empty for exact host) and whether host matches.
+func (pattern *hostPattern) match(host string) (string, bool) {
+	if pattern.param == "" {
+		return "", host == pattern.suffix
+	}
+	label, ok := strings.CutSuffix(host, pattern.suffix)
+	if !ok || label == "" || strings.Contains(label, ".") {
+		return "", false
+	}
+	return label, true
+}
+
+// Engine of routers bound to `pattern`, it has the same global middlewares and settings as `engine`.
+func getHostEngine(engine *gin.Engine, pattern *hostPattern) *gin.Engine {
+	engines, ok := hostEngines[engine]
+	if !ok {
+		engines = make(map[*hostPattern]*gin.Engine)
+		hostEngines[engine] = engines
+	}
+	if hostEngine, ok := engines[pattern]; ok {
+		return hostEngine
+	}
+
+	hostEngine := gin.New()
+	hostEngine.RedirectTrailingSlash = engine.RedirectTrailingSlash
+	hostEngine.RedirectFixedPath = engine.RedirectFixedPath
+	hostEngine.HandleMethodNotAllowed = engine.HandleMethodNotAllowed
+	hostEngine.ForwardedByClientIP = engine.ForwardedByClientIP
+	hostEngine.RemoteIPHeaders = engine.RemoteIPHeaders
+	hostEngine.TrustedPlatform = engine.TrustedPlatform
+	hostEngine.UseRawPath = engine.UseRawPath
+	hostEngine.UnescapePathValues = engine.UnescapePathValues
+	hostEngine.RemoveExtraSlash = engine.RemoveExtraSlash
+	hostEngine.ContextWithFallback = engine.ContextWithFallback
+	hostEngine.MaxMultipartMemory = engine.MaxMultipartMemory
+	hostEngine.Use(hostMiddleware(pattern))
+	hostEngine.Use(engine.Handlers...)
+	engines[pattern] = hostEngine
+	return hostEngine
+}
+
+// Dispatch requests to engines of routers bound to host according to Host header. Requests are
+// dispatched to `engine` if none of the host's routes matches, so they fall back to routers not bound to host.
+func newHostHandler(engine *gin.Engine) http.Handler {
+	engines := hostEngines[engine]
+	if len(engines) == 0 {
+		return engine
+	}
+	// host pattern => method => full paths of routes
+	routePaths := make(map[*hostPattern]map[string][]string)
+	for pattern, hostEngine := range engines {
+		routePaths[pattern] = make(map[string][]string)
+		for _, route := range hostEngine.Routes() {
+			routePaths[pattern][route.Method] = append(routePaths[pattern][route.Method], route.Path)
+		}
+	}
+	return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		pattern, _ := matchHostPattern(req.Host)
+		if hostEngine, ok := engines[pattern]; ok && matchHostRoute(routePaths[pattern][req.Method], req.URL.Path) {
+			hostEngine.ServeHTTP(w, req)
+			return
+		}
+		engine.ServeHTTP(w, req)
+	})
+}
+
+// Return the first registered host pattern which matches Host header and the captured label,
+// or nil if none matches.
+func matchHostPattern(host string) (*hostPattern, string) {
+	host = normalizeHost(host)
+	for _, pattern := range hostPatterns {
+		if label, ok := pattern.match(host); ok {
+			return pattern, label
+		}
+	}
+	return nil, ""
+}
+
+// e.g. API.example.com:8080 => api.example.com
+func normalizeHost(host string) string {
+	if h, _, err := net.SplitHostPort(host); err == nil {
+		host = h
+	}
+	return strings.TrimSuffix(strings.ToLower(host), ".")
+}
+
+// Path with or without trailing slash is matched, the former is redirected by gin (RedirectTrailingSlash).
+func matchHostRoute(fullPaths []string, urlPath string) bool {
+	alternative := urlPath + "/"
+	if trimmed, ok := strings.CutSuffix(urlPath, "/"); ok {
+		alternative = trimmed
+	}
+	return slices.ContainsFunc(fullPaths, func(fullPath string) bool {
+		return matchFullPath(fullPath, urlPath) || matchFullPath(fullPath, alternative)
+	})
+}
+
+// Set host pattern of the engine, and path param captured by wildcard of host pattern.
+func hostMiddleware(pattern *hostPattern) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		c.Set(hostPatternKey, pattern)
+		if pattern.param == "" {
+			return
+		}
+		if label, ok := pattern.match(normalizeHost(c.Request.Host)); ok {
+			c.Params = append(c.Params, gin.Param{Key: pattern.param, Value: label})
+		}
+	}
+}
+
+// Host pattern of routers which handle the request, it is nil if they are not bound to host.
+func getRequestHostPattern(c *gin.Context) *hostPattern {
+	value, _ := c.Get(hostPatternKey)
+	pattern, _ := value.(*hostPattern)
+	return pattern
+}
+
+// full path shown to users, e.g. /api => api.example.com/api. pattern can be nil
+func (pattern *hostPattern) displayPath(fullPath string) string {
+	if pattern == nil {
+		return fullPath
+	}
+	return pattern.pattern + fullPath
+}
+
+// Key of the route, routes of different hosts have different keys.
+// e.g. GET /user/:id, or GET api.example.com/user/:id for routers bound to host
+func routeKey(pattern *hostPattern, method string, fullPath string) string {
+	return method + " " + pattern.displayPath(fullPath)
+}
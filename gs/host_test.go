@@ -0,0 +1,139 @@
+package gs
+
+import (
+	"encoding/json"
+	"net/http"
+	"testing"
+
+	"github.com/dan-kuroto/gin-stronger/config"
+	"github.com/gin-gonic/gin"
+)
+
+func TestHostRouting(t *testing.T) {
+	setupTest(t, nil)
+	rootRouter.Children = []Router{
+		{Path: "/api", Host: "api.example.com", Children: []Router{
+			{Path: "/users", Method: GET, Handlers: []gin.HandlerFunc{stringHandler("api users")}},
+		}},
+		{Path: "/user/:id", Host: ":tenant.example.com", Method: GET, Handlers: []gin.HandlerFunc{func(c *gin.Context) {
+			c.String(http.StatusOK, c.Param("tenant")+" "+c.Param("id")+" "+c.FullPath()+" "+c.Request.URL.Path)
+		}}},
+		{Path: "/health", Method: GET, Handlers: []gin.HandlerFunc{stringHandler("ok")}},
+	}
+	handler := newTestHandler(t)
+
+	tests := []struct {
+		host, path string
+		status     int
+		body       string
+	}{
+		{"api.example.com", "/api/users", http.StatusOK, "api users"},
+		{"API.example.com:8080", "/api/users", http.StatusOK, "api users"},
+		{"other.com", "/api/users", http.StatusNotFound, ""},
+		// full path and request path are the same as routers not bound to host
+		{"acme.example.com", "/user/1", http.StatusOK, "acme 1 /user/:id /user/1"},
+		// fall back to routers not bound to host
+		{"api.example.com", "/health", http.StatusOK, "ok"},
+		{"other.com", "/health", http.StatusOK, "ok"},
+	}
+	for _, test := range tests {
+		w := doRequest(handler, testRequest{target: test.path, header: map[string]string{"Host": test.host}})
+		if w.Code != test.status || (test.body != "" && w.Body.String() != test.body) {
+			t.Errorf("%s%s: got %d %q, want %d %q", test.host, test.path, w.Code, w.Body.String(), test.status, test.body)
+		}
+	}
+}
+
+func TestHostRoutingRedirectTrailingSlash(t *testing.T) {
+	setupTest(t, nil)
+	rootRouter.Children = []Router{
+		{Path: "/api/users", Host: "api.example.com", Method: GET, Handlers: []gin.HandlerFunc{stringHandler("api users")}},
+		{Path: "/users", Host: ":tenant.example.com", Method: GET, Handlers: []gin.HandlerFunc{stringHandler("tenant users")}},
+	}
+	handler := newTestHandler(t)
+
+	tests := []struct{ host, path, location, body string }{
+		{"api.example.com", "/api/users/", "/api/users", "api users"},
+		{"acme.example.com", "/users/", "/users", "tenant users"},
+	}
+	for _, test := range tests {
+		header := map[string]string{"Host": test.host}
+		w := doRequest(handler, testRequest{target: test.path, header: header})
+		if w.Code != http.StatusMovedPermanently || w.Header().Get("Location") != test.location {
+			t.Fatalf("%s%s: got %d Location %q, want 301 %q", test.host, test.path, w.Code, w.Header().Get("Location"), test.location)
+		}
+		w = doRequest(handler, testRequest{target: w.Header().Get("Location"), header: header})
+		if w.Code != http.StatusOK || w.Body.String() != test.body {
+			t.Errorf("%s%s: redirect got %d %q, want 200 %q", test.host, test.path, w.Code, w.Body.String(), test.body)
+		}
+	}
+}
+
+func TestValidateHostWithoutRegistering(t *testing.T) {
+	setupTest(t, nil)
+	router := Router{Path: "", Children: []Router{
+		{Path: "/a", Host: "a.example.com", Method: GET, Handlers: []gin.HandlerFunc{stringHandler("a")}},
+		{Path: "/a", Host: "b.example.com", Method: GET, Handlers: []gin.HandlerFunc{stringHandler("b")}},
+		{Path: "/a", Host: "a.example.com", Method: GET, Handlers: []gin.HandlerFunc{stringHandler("duplicate")}},
+	}}
+	err := ValidateRouter(&router)
+	if errs, ok := err.(RouterErrors); !ok || len(errs) != 1 {
+		t.Errorf("got %v, want only duplicate route of a.example.com", err)
+	}
+	if len(hostPatterns) != 0 {
+		t.Errorf("validation registered %d host patterns", len(hostPatterns))
+	}
+}
+
+func TestHostFallbackRunsGlobalMiddlewaresOnce(t *testing.T) {
+	setupTest(t, func(cfg *config.Configuration) {
+		cfg.CORS.Enable = true
+		cfg.CORS.AllowOrigins = []string{"*"}
+	})
+	rootRouter.Children = []Router{
+		{Path: "/api", Host: "api.example.com", Method: GET, Handlers: []gin.HandlerFunc{stringHandler("api")}},
+		{Path: "/health", Method: GET, Handlers: []gin.HandlerFunc{stringHandler("ok")}},
+	}
+	handler := newTestHandler(t)
+
+	w := doRequest(handler, testRequest{target: "/health", header: map[string]string{"Host": "api.example.com", "Origin": "https://a.com"}})
+	if w.Code != http.StatusOK || len(w.Header().Values("Vary")) != 1 {
+		t.Errorf("got %d Vary %v, want 200 and one Vary", w.Code, w.Header().Values("Vary"))
+	}
+}
+
+func TestHostCORSAndOpenAPI(t *testing.T) {
+	setupTest(t, func(cfg *config.Configuration) {
+		cfg.OpenAPI.Enable = true
+	})
+	rootRouter.Children = []Router{
+		{Path: "/items", Host: "a.example.com", Method: GET, CORS: &config.CORSPolicy{AllowOrigins: []string{"https://a.com"}},
+			Summary: "a items", Handlers: []gin.HandlerFunc{stringHandler("a")}},
+		{Path: "/items", Host: "b.example.com", Method: GET, CORS: &config.CORSPolicy{AllowOrigins: []string{"https://b.com"}},
+			Summary: "b items", Handlers: []gin.HandlerFunc{stringHandler("b")}},
+		{Path: "/items", Method: GET, Summary: "items", Handlers: []gin.HandlerFunc{stringHandler("items")}},
+	}
+	handler := newTestHandler(t)
+
+	// policies of the same path on different hosts don't override each other
+	for _, host := range []string{"a", "b"} {
+		w := doRequest(handler, testRequest{target: "/items", header: map[string]string{
+			"Host": host + ".example.com", "Origin": "https://" + host + ".com",
+		}})
+		if w.Body.String() != host || w.Header().Get("Access-Control-Allow-Origin") != "https://"+host+".com" {
+			t.Errorf("%s: got %q, allow origin %q", host, w.Body.String(), w.Header().Get("Access-Control-Allow-Origin"))
+		}
+	}
+
+	tests := map[string]string{"a.example.com": "a items", "b.example.com": "b items", "other.com": "items"}
+	for host, summary := range tests {
+		w := doRequest(handler, testRequest{target: "/openapi.json", header: map[string]string{"Host": host}})
+		var doc OpenAPIDocument
+		if err := json.Unmarshal(w.Body.Bytes(), &doc); err != nil {
+			t.Fatalf("%s: got %d %q: %v", host, w.Code, w.Body.String(), err)
+		}
+		if operation := doc.Paths["/items"]["get"]; operation == nil || operation.Summary != summary {
+			t.Errorf("%s: got operation %+v, want summary %q", host, operation, summary)
+		}
+	}
+}
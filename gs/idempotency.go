@@ -0,0 +1,284 @@
+package gs
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"io"
+	"net/http"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/rs/zerolog/log"
+)
+
+// Idempotency of unsafe requests (e.g. POST and PATCH) with Idempotency-Key header, responses are
+// stored by key, route and client, and replayed for retries. It's shared by all children of the router.
+type Idempotency struct {
+	// how long responses are kept. default value is 24h
+	TTL time.Duration `yaml:"ttl"`
+	// How to identify clients, default value is user. Keys of different clients never conflict.
+	// See `RateLimit.Key` for available values.
+	Key string `yaml:"key"`
+	// respond 400 to unsafe requests without Idempotency-Key
+	Required bool `yaml:"required"`
+}
+
+const defaultIdempotencyTTL = 24 * time.Hour
+
+// Stored state of an idempotency key.
+type IdempotencyRecord struct {
+	// hash of request method and body, requests with the same key must have the same fingerprint
+	Fingerprint string
+	// false while the first request is being handled
+	Completed bool
+	Status    int
+	Header    http.Header
+	Body      []byte
+}
+
+// Storage of idempotency records, it should be safe for concurrent use.
+type IdempotencyStore interface {
+	// Save `record` if `key` does not exist and return nil, otherwise return the existing record.
+	// It must be atomic, so that only one of concurrent requests can start.
+	Start(ctx context.Context, key string, record *IdempotencyRecord, ttl time.Duration) (*IdempotencyRecord, error)
+	// Replace the record of `key` with the completed one.
+	Complete(ctx context.Context, key string, record *IdempotencyRecord, ttl time.Duration) error
+	// Remove the record of `key`, so that the request can be retried.
+	Delete(ctx context.Context, key string) error
+}
+
+var idempotencyStore IdempotencyStore = NewMemoryIdempotencyStore()
+
+// Replace the default in-memory store, e.g. with one shared by all instances.
+func SetIdempotencyStore(store IdempotencyStore) {
+	idempotencyStore = store
+}
+
+// Handle Idempotency-Key of unsafe requests:
+//   - the first request is handled and its response is stored, unless it fails with 5xx or panics
+//   - retries get the stored response with `Idempotent-Replayed: true` header
+//   - 409 if the first request is still being handled
+//   - 422 if the key was used by a request with different method or body
+//
+// Store errors respond 503 rather than allowing the request, since it may be handled twice.
+func idempotencyMiddleware(idempotency Idempotency) gin.HandlerFunc {
+	if idempotency.TTL < 0 {
+		panic("ttl of idempotency must be positive")
+	}
+	if idempotency.TTL == 0 {
+		idempotency.TTL = defaultIdempotencyTTL
+	}
+	if idempotency.Key == "" {
+		idempotency.Key = "user"
+	}
+
+	return func(c *gin.Context) {
+		switch c.Request.Method {
+		case http.MethodGet, http.MethodHead, http.MethodOptions, http.MethodTrace:
+			return
+		}
+		idempotencyKey := c.GetHeader("Idempotency-Key")
+		if idempotencyKey == "" {
+			if idempotency.Required {
+				AbortWithError(c, http.StatusBadRequest, "Idempotency-Key header is required")
+			}
+			return
+		}
+
+		fingerprint, ok := getRequestFingerprint(c)
+		if !ok {
+			return
+		}
+		ctx := c.Request.Context()
+		key := routeKey(getRequestHostPattern(c), c.Request.Method, c.FullPath()) + ":" + getRateLimitClientKey(c, idempotency.Key) + ":" + idempotencyKey
+		existing, err := idempotencyStore.Start(ctx, key, &IdempotencyRecord{Fingerprint: fingerprint}, idempotency.TTL)
+		if err != nil {
+			log.Err(err).Str("key", key).Msg("idempotency store failed")
+			AbortWithError(c, http.StatusServiceUnavailable, "idempotency store is unavailable")
+			return
+		}
+		if existing != nil {
+			replayIdempotencyRecord(c, existing, fingerprint)
+			return
+		}
+
+		writer := &recordWriter{ResponseWriter: c.Writer, initial: c.Writer.Header().Clone()}
+		c.Writer = writer
+		completed := false
+		defer func() {
+			c.Writer = writer.ResponseWriter
+			if completed {
+				return
+			}
+			// panicked or failed, it can be retried with the same key
+			if err := idempotencyStore.Delete(context.WithoutCancel(ctx), key); err != nil {
+				log.Err(err).Str("key", key).Msg("idempotency store failed")
+			}
+		}()
+		c.Next()
+
+		status := writer.Status()
+		if status >= http.StatusInternalServerError {
+			return
+		}
+		record := &IdempotencyRecord{
+			Fingerprint: fingerprint,
+			Completed:   true,
+			Status:      status,
+			Header:      writer.handlerHeader(),
+			Body:        writer.body.Bytes(),
+		}
+		if err := idempotencyStore.Complete(context.WithoutCancel(ctx), key, record, idempotency.TTL); err != nil {
+			log.Err(err).Str("key", key).Msg("idempotency store failed")
+			return
+		}
+		completed = true
+	}
+}
+
+// Hash of request method and body, the body is restored for handlers.
+func getRequestFingerprint(c *gin.Context) (string, bool) {
+	hash := sha256.New()
+	io.WriteString(hash, c.Request.Method+"\n")
+	if c.Request.Body != nil {
+		body, err := io.ReadAll(c.Request.Body)
+		if err != nil {
+			if !abortIfBodyTooLarge(c, err) {
+				AbortWithError(c, http.StatusBadRequest, "read request body failed")
+			}
+			return "", false
+		}
+		c.Request.Body = io.NopCloser(bytes.NewReader(body))
+		hash.Write(body)
+	}
+	return hex.EncodeToString(hash.Sum(nil)), true
+}
+
+func replayIdempotencyRecord(c *gin.Context, record *IdempotencyRecord, fingerprint string) {
+	if record.Fingerprint != fingerprint {
+		AbortWithError(c, http.StatusUnprocessableEntity, "Idempotency-Key is used by a different request")
+		return
+	}
+	if !record.Completed {
+		AbortWithError(c, http.StatusConflict, "request with the same Idempotency-Key is being processed")
+		return
+	}
+	header := c.Writer.Header()
+	for name, values := range record.Header {
+		header[name] = values
+	}
+	header.Set("Idempotent-Replayed", "true")
+	c.Writer.WriteHeader(record.Status)
+	c.Writer.Write(record.Body)
+	c.Abort()
+}
+
+// Response writer which keeps a copy of the body and headers set by the handler.
+type recordWriter struct {
+	gin.ResponseWriter
+	// headers set before the handler, e.g. by CORS and rate limit middlewares
+	initial http.Header
+	// headers when the handler starts writing, before they are changed by outer writers (e.g. compressWriter)
+	header http.Header
+	body   bytes.Buffer
+}
+
+func (w *recordWriter) snapshot() {
+	if w.header == nil {
+		w.header = w.Header().Clone()
+	}
+}
+
+func (w *recordWriter) Write(data []byte) (int, error) {
+	w.snapshot()
+	w.body.Write(data)
+	return w.ResponseWriter.Write(data)
+}
+
+func (w *recordWriter) WriteString(s string) (int, error) {
+	w.snapshot()
+	w.body.WriteString(s)
+	return w.ResponseWriter.WriteString(s)
+}
+
+func (w *recordWriter) WriteHeaderNow() {
+	w.snapshot()
+	w.ResponseWriter.WriteHeaderNow()
+}
+
+func (w *recordWriter) Flush() {
+	w.snapshot()
+	w.ResponseWriter.Flush()
+}
+
+// Headers added or changed by the handler, the others are set again when the response is replayed.
+func (w *recordWriter) handlerHeader() http.Header {
+	w.snapshot()
+	header := make(http.Header)
+	for name, values := range w.header {
+		if !slices.Equal(w.initial[name], values) {
+			header[name] = values
+		}
+	}
+	return header
+}
+
+// Default store, records are kept in memory of the process.
+type MemoryIdempotencyStore struct {
+	mutex   sync.Mutex
+	entries map[string]*memoryIdempotencyEntry
+	sweptAt time.Time
+}
+
+type memoryIdempotencyEntry struct {
+	record    *IdempotencyRecord
+	expiresAt time.Time
+}
+
+func NewMemoryIdempotencyStore() *MemoryIdempotencyStore {
+	return &MemoryIdempotencyStore{entries: make(map[string]*memoryIdempotencyEntry), sweptAt: time.Now()}
+}
+
+func (store *MemoryIdempotencyStore) Start(_ context.Context, key string, record *IdempotencyRecord, ttl time.Duration) (*IdempotencyRecord, error) {
+	store.mutex.Lock()
+	defer store.mutex.Unlock()
+
+	now := time.Now()
+	store.sweep(now)
+	if entry, ok := store.entries[key]; ok && now.Before(entry.expiresAt) {
+		return entry.record, nil
+	}
+	store.entries[key] = &memoryIdempotencyEntry{record: record, expiresAt: now.Add(ttl)}
+	return nil, nil
+}
+
+func (store *MemoryIdempotencyStore) Complete(_ context.Context, key string, record *IdempotencyRecord, ttl time.Duration) error {
+	store.mutex.Lock()
+	defer store.mutex.Unlock()
+	store.entries[key] = &memoryIdempotencyEntry{record: record, expiresAt: time.Now().Add(ttl)}
+	return nil
+}
+
+func (store *MemoryIdempotencyStore) Delete(_ context.Context, key string) error {
+	store.mutex.Lock()
+	defer store.mutex.Unlock()
+	delete(store.entries, key)
+	return nil
+}
+
+// Remove expired entries at most once per minute.
+func (store *MemoryIdempotencyStore) sweep(now time.Time) {
+	if now.Sub(store.sweptAt) < time.Minute {
+		return
+	}
+	store.sweptAt = now
+	for key, entry := range store.entries {
+		if now.After(entry.expiresAt) {
+			delete(store.entries, key)
+		}
+	}
+}
@@ -0,0 +1,120 @@
+package gs
+
+import (
+	"compress/gzip"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/dan-kuroto/gin-stronger/config"
+	"github.com/gin-gonic/gin"
+)
+
+func TestIdempotency(t *testing.T) {
+	setupTest(t, nil)
+	calls := 0
+	rootRouter.Children = []Router{{Path: "/pay", Idempotency: &Idempotency{Key: "ip"}, Children: []Router{
+		{Path: "", Method: POST, Handlers: []gin.HandlerFunc{func(c *gin.Context) {
+			calls++
+			c.Header("X-Call", strconv.Itoa(calls))
+			c.JSON(http.StatusCreated, gin.H{"call": calls})
+		}}},
+		{Path: "/fail", Method: POST, Handlers: []gin.HandlerFunc{func(c *gin.Context) {
+			calls++
+			c.String(http.StatusInternalServerError, "failed")
+		}}},
+		{Path: "/required", Method: POST, Idempotency: &Idempotency{Required: true}, Handlers: []gin.HandlerFunc{stringHandler("ok")}},
+	}}}
+	handler := newTestHandler(t)
+	post := func(target, key, body string) (int, string, http.Header) {
+		w := doRequest(handler, testRequest{method: http.MethodPost, target: target, body: body, header: map[string]string{"Idempotency-Key": key}})
+		return w.Code, w.Body.String(), w.Header()
+	}
+
+	status, body, header := post("/pay", "k1", "a")
+	if status != http.StatusCreated || body != `{"call":1}` || header.Get("Idempotent-Replayed") != "" {
+		t.Fatalf("first request: got %d %q", status, body)
+	}
+	status, body, header = post("/pay", "k1", "a")
+	if status != http.StatusCreated || body != `{"call":1}` || header.Get("X-Call") != "1" || header.Get("Idempotent-Replayed") != "true" {
+		t.Errorf("retry: got %d %q %v", status, body, header)
+	}
+	if status, _, _ = post("/pay", "k1", "b"); status != http.StatusUnprocessableEntity {
+		t.Errorf("different body: got %d, want 422", status)
+	}
+	if status, body, _ = post("/pay", "", "a"); status != http.StatusCreated || body != `{"call":2}` {
+		t.Errorf("without key: got %d %q", status, body)
+	}
+	post("/pay/fail", "k2", "a")
+	post("/pay/fail", "k2", "a")
+	if calls != 4 {
+		t.Errorf("failed request is not retried, calls = %d", calls)
+	}
+	if status, _, _ = post("/pay/required", "", "a"); status != http.StatusBadRequest {
+		t.Errorf("required key: got %d, want 400", status)
+	}
+}
+
+func TestIdempotencyConcurrentDuplicate(t *testing.T) {
+	setupTest(t, nil)
+	started, release := make(chan struct{}), make(chan struct{})
+	rootRouter.Children = []Router{{Path: "/pay", Method: POST, Idempotency: &Idempotency{},
+		Handlers: []gin.HandlerFunc{func(c *gin.Context) {
+			close(started)
+			<-release
+			c.Status(http.StatusNoContent)
+		}}}}
+	handler := newTestHandler(t)
+	request := testRequest{method: http.MethodPost, target: "/pay", header: map[string]string{"Idempotency-Key": "k"}}
+
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		doRequest(handler, request)
+	}()
+	<-started
+	if w := doRequest(handler, request); w.Code != http.StatusConflict {
+		t.Errorf("concurrent duplicate: got %d, want 409", w.Code)
+	}
+	close(release)
+	wg.Wait()
+	if w := doRequest(handler, request); w.Code != http.StatusNoContent || w.Header().Get("Idempotent-Replayed") != "true" {
+		t.Errorf("retry: got %d, want replayed 204", w.Code)
+	}
+}
+
+func TestIdempotencyWithCompression(t *testing.T) {
+	setupTest(t, func(cfg *config.Configuration) {
+		cfg.Compression.Enable = true
+	})
+	text := strings.Repeat("a", 4096)
+	requests := 0
+	rootRouter.Children = []Router{{Path: "/pay", Method: POST, Idempotency: &Idempotency{},
+		MiddleWares: []gin.HandlerFunc{func(c *gin.Context) {
+			requests++
+			c.Header("X-Request", strconv.Itoa(requests))
+		}},
+		Handlers: []gin.HandlerFunc{stringHandler(text)}}}
+	handler := newTestHandler(t)
+
+	for i := 1; i <= 2; i++ {
+		w := doRequest(handler, testRequest{method: http.MethodPost, target: "/pay", header: map[string]string{
+			"Idempotency-Key": "k", "Accept-Encoding": "gzip",
+		}})
+		if w.Header().Get("Content-Encoding") != "gzip" || w.Header().Get("X-Request") != strconv.Itoa(i) {
+			t.Fatalf("request %d: got headers %v", i, w.Header())
+		}
+		reader, err := gzip.NewReader(w.Body)
+		if err != nil {
+			t.Fatalf("request %d: %v", i, err)
+		}
+		body, err := io.ReadAll(reader)
+		if err != nil || string(body) != text {
+			t.Errorf("request %d: body is not the gzipped text, err = %v", i, err)
+		}
+	}
+}
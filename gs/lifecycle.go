@@ -0,0 +1,113 @@
+package gs
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/rs/zerolog/log"
+)
+
+type Phase string
+
+const (
+	// config is loaded, id generators are not initialized yet
+	PhaseConfigLoaded Phase = "config-loaded"
+	// before routers are validated and registered, it is the last chance to call `UseController`
+	PhaseBeforeRoutes Phase = "before-routes"
+	// gin engine is built with all routes, see `GetEngine`
+	PhaseEngineBuilt Phase = "engine-built"
+	// all listeners are accepting connections
+	PhaseServerStarted Phase = "server-started"
+	// servers and scheduler are stopped
+	PhaseShutdown Phase = "shutdown"
+)
+
+type Hook struct {
+	// used in logs, it is suggested to be unique
+	Name  string
+	Phase Phase
+	// hooks of the same phase are called by priority ascending, and by registration order if priorities are equal
+	Priority int
+	// ctx of Func is canceled after timeout, and the hook is regarded as failed. no timeout if zero
+	Timeout time.Duration
+	// error returned by hooks before PhaseShutdown aborts startup
+	Func func(ctx context.Context) error
+}
+
+var hooks = make([]Hook, 0)
+
+var engine *gin.Engine
+
+// Register lifecycle hook, multiple hooks can be registered for the same phase.
+func AddHook(hook Hook) {
+	hooks = append(hooks, hook)
+}
+
+// Get gin engine of public listener, it is nil before PhaseEngineBuilt.
+func GetEngine() *gin.Engine {
+	return engine
+}
+
+// Call hooks of the phase in order. For PhaseShutdown all hooks are called even if some fail,
+// otherwise it stops at the first failure.
+func runHooks(phase Phase) error {
+	phaseHooks := make([]Hook, 0)
+	for _, hook := range hooks {
+		if hook.Phase == phase {
+			phaseHooks = append(phaseHooks, hook)
+		}
+	}
+	sort.SliceStable(phaseHooks, func(i, j int) bool {
+		return phaseHooks[i].Priority < phaseHooks[j].Priority
+	})
+
+	var firstErr error
+	for _, hook := range phaseHooks {
+		if err := runHook(&hook); err != nil {
+			err = fmt.Errorf("hook %s of phase %s failed: %w", hook.Name, phase, err)
+			if phase != PhaseShutdown {
+				return err
+			}
+			log.Err(err).Send()
+			if firstErr == nil {
+				firstErr = err
+			}
+		}
+	}
+	return firstErr
+}
+
+func runHook(hook *Hook) error {
+	ctx := context.Background()
+	if hook.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, hook.Timeout)
+		defer cancel()
+	}
+
+	done := make(chan error, 1)
+	go func() {
+		defer func() {
+			if r := recover(); r != nil {
+				done <- fmt.Errorf("panic: %v", r)
+			}
+		}()
+		done <- hook.Func(ctx)
+	}()
+	select {
+	case err := <-done:
+		return err
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// Run hooks of startup phase, exit if any of them fails.
+func mustRunHooks(phase Phase) {
+	if err := runHooks(phase); err != nil {
+		log.Fatal().Err(err).Msg("startup aborted")
+	}
+}
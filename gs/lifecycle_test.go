@@ -0,0 +1,104 @@
+package gs
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+)
+
+// hook appending its name to `calls` and returning `err`
+func recordHook(calls *[]string, name string, phase Phase, priority int, err error) Hook {
+	return Hook{Name: name, Phase: phase, Priority: priority, Func: func(ctx context.Context) error {
+		*calls = append(*calls, name)
+		return err
+	}}
+}
+
+func TestRunHooksOrder(t *testing.T) {
+	setupTest(t, nil)
+	calls := make([]string, 0)
+	AddHook(recordHook(&calls, "c", PhaseBeforeRoutes, 10, nil))
+	AddHook(recordHook(&calls, "a1", PhaseBeforeRoutes, -1, nil))
+	AddHook(recordHook(&calls, "other", PhaseEngineBuilt, 0, nil))
+	AddHook(recordHook(&calls, "b1", PhaseBeforeRoutes, 0, nil))
+	AddHook(recordHook(&calls, "a2", PhaseBeforeRoutes, -1, nil))
+	AddHook(recordHook(&calls, "b2", PhaseBeforeRoutes, 0, nil))
+
+	if err := runHooks(PhaseBeforeRoutes); err != nil {
+		t.Fatal(err)
+	}
+	if got, want := strings.Join(calls, ","), "a1,a2,b1,b2,c"; got != want {
+		t.Errorf("got calls %s, want %s", got, want)
+	}
+}
+
+func TestRunHooksStartupError(t *testing.T) {
+	setupTest(t, nil)
+	calls := make([]string, 0)
+	failure := errors.New("failure")
+	AddHook(recordHook(&calls, "first", PhaseServerStarted, 0, nil))
+	AddHook(recordHook(&calls, "failing", PhaseServerStarted, 1, failure))
+	AddHook(recordHook(&calls, "skipped", PhaseServerStarted, 2, nil))
+
+	err := runHooks(PhaseServerStarted)
+	if !errors.Is(err, failure) || !strings.Contains(err.Error(), "hook failing of phase server-started") {
+		t.Errorf("got error %v, want failure of hook failing", err)
+	}
+	if got, want := strings.Join(calls, ","), "first,failing"; got != want {
+		t.Errorf("got calls %s, want %s", got, want)
+	}
+}
+
+func TestRunHooksShutdownContinues(t *testing.T) {
+	setupTest(t, nil)
+	calls := make([]string, 0)
+	failure := errors.New("failure")
+	AddHook(recordHook(&calls, "failing", PhaseShutdown, 0, failure))
+	AddHook(recordHook(&calls, "second", PhaseShutdown, 1, errors.New("another failure")))
+	AddHook(recordHook(&calls, "last", PhaseShutdown, 2, nil))
+
+	if err := runHooks(PhaseShutdown); !errors.Is(err, failure) {
+		t.Errorf("got error %v, want the first failure", err)
+	}
+	if got, want := strings.Join(calls, ","), "failing,second,last"; got != want {
+		t.Errorf("got calls %s, want %s", got, want)
+	}
+}
+
+func TestRunHooksTimeout(t *testing.T) {
+	setupTest(t, nil)
+	canceled := make(chan struct{})
+	AddHook(Hook{Name: "slow", Phase: PhaseEngineBuilt, Timeout: 10 * time.Millisecond, Func: func(ctx context.Context) error {
+		<-ctx.Done()
+		close(canceled)
+		return nil
+	}})
+
+	if err := runHooks(PhaseEngineBuilt); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("got error %v, want %v", err, context.DeadlineExceeded)
+	}
+	select {
+	case <-canceled:
+	case <-time.After(time.Second):
+		t.Error("ctx of hook is not canceled after timeout")
+	}
+}
+
+func TestRunHooksPanic(t *testing.T) {
+	setupTest(t, nil)
+	calls := make([]string, 0)
+	AddHook(Hook{Name: "panicking", Phase: PhaseShutdown, Func: func(ctx context.Context) error {
+		panic("boom")
+	}})
+	AddHook(recordHook(&calls, "after", PhaseShutdown, 1, nil))
+
+	err := runHooks(PhaseShutdown)
+	if err == nil || !strings.Contains(err.Error(), "panic: boom") {
+		t.Errorf("got error %v, want panic: boom", err)
+	}
+	if len(calls) != 1 {
+		t.Errorf("got calls %v, want hook after the panic called", calls)
+	}
+}
@@ -0,0 +1,415 @@
+package gs
+
+import (
+	"encoding/json"
+	"net/http"
+	"reflect"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"gopkg.in/yaml.v3"
+)
+
+type OpenAPIDocument struct {
+	OpenAPI    string                     `json:"openapi"`
+	Info       OpenAPIInfo                `json:"info"`
+	Paths      map[string]OpenAPIPathItem `json:"paths"`
+	Components OpenAPIComponents          `json:"components"`
+}
+
+type OpenAPIInfo struct {
+	Title   string `json:"title"`
+	Version string `json:"version"`
+}
+
+// key is lower case http method
+type OpenAPIPathItem map[string]*OpenAPIOperation
+
+type OpenAPIOperation struct {
+	OperationId string                      `json:"operationId,omitempty"`
+	Summary     string                      `json:"summary,omitempty"`
+	Deprecated  bool                        `json:"deprecated,omitempty"`
+	Parameters  []OpenAPIParameter          `json:"parameters,omitempty"`
+	RequestBody *OpenAPIRequestBody         `json:"requestBody,omitempty"`
+	Responses   map[string]*OpenAPIResponse `json:"responses"`
+}
+
+type OpenAPIParameter struct {
+	Name     string         `json:"name"`
+	In       string         `json:"in"`
+	Required bool           `json:"required,omitempty"`
+	Schema   *OpenAPISchema `json:"schema"`
+}
+
+type OpenAPIRequestBody struct {
+	Required bool                        `json:"required,omitempty"`
+	Content  map[string]OpenAPIMediaType `json:"content"`
+}
+
+type OpenAPIResponse struct {
+	Description string                      `json:"description"`
+	Content     map[string]OpenAPIMediaType `json:"content,omitempty"`
+}
+
+type OpenAPIMediaType struct {
+	Schema *OpenAPISchema `json:"schema"`
+}
+
+type OpenAPISchema struct {
+	Ref                  string                    `json:"$ref,omitempty"`
+	Type                 string                    `json:"type,omitempty"`
+	Format               string                    `json:"format,omitempty"`
+	Items                *OpenAPISchema            `json:"items,omitempty"`
+	Properties           map[string]*OpenAPISchema `json:"properties,omitempty"`
+	AdditionalProperties *OpenAPISchema            `json:"additionalProperties,omitempty"`
+	Required             []string                  `json:"required,omitempty"`
+	Nullable             bool                      `json:"nullable,omitempty"`
+}
+
+type OpenAPIComponents struct {
+	Schemas map[string]*OpenAPISchema `json:"schemas,omitempty"`
+}
+
+var timeType = reflect.TypeOf(time.Time{})
+var schemaNameReplacer = regexp.MustCompile(`[^A-Za-z0-9_.-]+`)
+
+type openAPIBuilder struct {
+	doc *OpenAPIDocument
+	// routers bound to the host are included, see `Router.Host`
+	host *hostPattern
+	// type => schema name in components
+	schemaNames map[reflect.Type]string
+}
+
+// Generate OpenAPI 3 document by the routers registered through `UseController`,
+// request and result types are got from `Router.Function`.
+//
+// Routers bound to host are excluded, they are in the document served for requests of the host.
+func GetOpenAPIDocument() *OpenAPIDocument {
+	return getOpenAPIDocument(nil)
+}
+
+// Document of routers not bound to host and routers bound to `host`, the latter take precedence
+// like `newHostHandler` does.
+func getOpenAPIDocument(host *hostPattern) *OpenAPIDocument {
+	cfg := getOpenAPIConfig()
+	builder := &openAPIBuilder{
+		host: host,
+		doc: &OpenAPIDocument{
+			OpenAPI:    "3.0.3",
+			Info:       OpenAPIInfo{Title: cfg.Title, Version: cfg.Version},
+			Paths:      make(map[string]OpenAPIPathItem),
+			Components: OpenAPIComponents{Schemas: make(map[string]*OpenAPISchema)},
+		},
+		schemaNames: make(map[reflect.Type]string),
+	}
+	builder.addRouter("/", &rootRouter, deprecation{}, false)
+	return builder.doc
+}
+
+// hostBound is whether the router or its parent is bound to host
+func (builder *openAPIBuilder) addRouter(basePath string, router *Router, parentDeprecation deprecation, hostBound bool) {
+	if router.Host != "" {
+		if builder.host == nil || !strings.EqualFold(router.Host, builder.host.pattern) {
+			return
+		}
+		hostBound = true
+	}
+	fullPath := joinPaths(basePath, router.Path)
+	routerDeprecation := parentDeprecation.inherit(router)
+	if len(router.Children) != 0 {
+		for i := range router.Children {
+			builder.addRouter(fullPath, &router.Children[i], routerDeprecation, hostBound)
+		}
+		return
+	}
+
+	apiPath, pathParams := toOpenAPIPath(fullPath)
+	pathItem, ok := builder.doc.Paths[apiPath]
+	if !ok {
+		pathItem = make(OpenAPIPathItem)
+		builder.doc.Paths[apiPath] = pathItem
+	}
+	methods := router.Method.names()
+	for _, method := range methods {
+		if _, ok := pathItem[strings.ToLower(method)]; ok && !hostBound {
+			continue
+		}
+		operation := builder.newOperation(method, router, pathParams)
+		operation.Deprecated = routerDeprecation.enabled()
+		// operationId must be unique
+		if len(methods) > 1 && operation.OperationId != "" {
+			operation.OperationId += "_" + method
+		}
+		pathItem[strings.ToLower(method)] = operation
+	}
+}
+
+func (builder *openAPIBuilder) newOperation(method string, router *Router, pathParams []string) *OpenAPIOperation {
+	operation := &OpenAPIOperation{
+		Summary:   router.Summary,
+		Responses: map[string]*OpenAPIResponse{},
+	}
+	for _, name := range pathParams {
+		operation.Parameters = append(operation.Parameters, OpenAPIParameter{
+			Name:     name,
+			In:       "path",
+			Required: true,
+			Schema:   &OpenAPISchema{Type: "string"},
+		})
+	}
+
+	// request and result types are known only for `Router.Function`
+	var info *handlerInfo
+	if router.Function != nil {
+		info, _ = newHandlerInfo(router.Function)
+	}
+	if info == nil {
+		operation.Responses["200"] = &OpenAPIResponse{Description: "OK"}
+		return operation
+	}
+
+	if router.Name != "" {
+		operation.OperationId = router.Name
+	} else {
+		operation.OperationId = router.getFunctionName()
+	}
+	for _, paramType := range info.paramTypes {
+		if paramType == ginContextType || paramType == contextType {
+			continue
+		}
+		if method == http.MethodGet || method == http.MethodHead || method == http.MethodDelete {
+			operation.Parameters = append(operation.Parameters, builder.newQueryParameters(paramType)...)
+		} else {
+			operation.RequestBody = &OpenAPIRequestBody{
+				Required: true,
+				Content: map[string]OpenAPIMediaType{
+					gin.MIMEJSON: {Schema: builder.schemaOf(paramType)},
+				},
+			}
+		}
+	}
+	response := &OpenAPIResponse{Description: "OK"}
+	if len(info.resultTypes) == 1 {
+		response.Content = map[string]OpenAPIMediaType{
+			gin.MIMEJSON: {Schema: builder.schemaOf(info.resultTypes[0])},
+		}
+	}
+	operation.Responses["200"] = response
+	return operation
+}
+
+// `ShouldBind` of GET request binds query by `form` tag (or field name).
+func (builder *openAPIBuilder) newQueryParameters(paramType reflect.Type) []OpenAPIParameter {
+	for paramType.Kind() == reflect.Ptr {
+		paramType = paramType.Elem()
+	}
+	if paramType.Kind() != reflect.Struct {
+		return nil
+	}
+	params := make([]OpenAPIParameter, 0, paramType.NumField())
+	for i := 0; i < paramType.NumField(); i++ {
+		field := paramType.Field(i)
+		if !field.IsExported() {
+			continue
+		}
+		if field.Anonymous && field.Tag.Get("form") == "" {
+			params = append(params, builder.newQueryParameters(field.Type)...)
+			continue
+		}
+		name := strings.Split(field.Tag.Get("form"), ",")[0]
+		if name == "-" {
+			continue
+		} else if name == "" {
+			name = field.Name
+		}
+		params = append(params, OpenAPIParameter{
+			Name:     name,
+			In:       "query",
+			Required: isRequiredField(field),
+			Schema:   builder.schemaOf(field.Type),
+		})
+	}
+	return params
+}
+
+func (builder *openAPIBuilder) schemaOf(t reflect.Type) *OpenAPISchema {
+	nullable := false
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+		nullable = true
+	}
+	if t == timeType {
+		return &OpenAPISchema{Type: "string", Format: "date-time", Nullable: nullable}
+	}
+
+	switch t.Kind() {
+	case reflect.Bool:
+		return &OpenAPISchema{Type: "boolean", Nullable: nullable}
+	case reflect.Int, reflect.Int64, reflect.Uint, reflect.Uint64:
+		return &OpenAPISchema{Type: "integer", Format: "int64", Nullable: nullable}
+	case reflect.Int8, reflect.Int16, reflect.Int32, reflect.Uint8, reflect.Uint16, reflect.Uint32:
+		return &OpenAPISchema{Type: "integer", Format: "int32", Nullable: nullable}
+	case reflect.Float32:
+		return &OpenAPISchema{Type: "number", Format: "float", Nullable: nullable}
+	case reflect.Float64:
+		return &OpenAPISchema{Type: "number", Format: "double", Nullable: nullable}
+	case reflect.String:
+		return &OpenAPISchema{Type: "string", Nullable: nullable}
+	case reflect.Slice, reflect.Array:
+		if t.Elem().Kind() == reflect.Uint8 {
+			return &OpenAPISchema{Type: "string", Format: "byte", Nullable: nullable}
+		}
+		return &OpenAPISchema{Type: "array", Items: builder.schemaOf(t.Elem()), Nullable: nullable}
+	case reflect.Map:
+		return &OpenAPISchema{Type: "object", AdditionalProperties: builder.schemaOf(t.Elem()), Nullable: nullable}
+	case reflect.Struct:
+		if t.Name() == "" {
+			return builder.newStructSchema(t)
+		}
+		return &OpenAPISchema{Ref: "#/components/schemas/" + builder.schemaName(t)}
+	default:
+		// interface, func, chan ... any value is acceptable
+		return &OpenAPISchema{}
+	}
+}
+
+func (builder *openAPIBuilder) schemaName(t reflect.Type) string {
+	if name, ok := builder.schemaNames[t]; ok {
+		return name
+	}
+
+	name := schemaNameReplacer.ReplaceAllString(t.Name(), "_")
+	for i := 2; builder.doc.Components.Schemas[name] != nil; i++ {
+		name = schemaNameReplacer.ReplaceAllString(t.Name(), "_") + "_" + strconv.Itoa(i)
+	}
+	builder.schemaNames[t] = name
+	// placeholder to support recursive struct
+	builder.doc.Components.Schemas[name] = &OpenAPISchema{}
+	*builder.doc.Components.Schemas[name] = *builder.newStructSchema(t)
+	return name
+}
+
+func (builder *openAPIBuilder) newStructSchema(t reflect.Type) *OpenAPISchema {
+	schema := &OpenAPISchema{Type: "object", Properties: make(map[string]*OpenAPISchema)}
+	builder.addStructFields(schema, t)
+	return schema
+}
+
+func (builder *openAPIBuilder) addStructFields(schema *OpenAPISchema, t reflect.Type) {
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		if !field.IsExported() {
+			continue
+		}
+		tag := strings.Split(field.Tag.Get("json"), ",")
+		name := tag[0]
+		if name == "-" {
+			continue
+		}
+		// embedded struct without json name is flattened, like encoding/json does
+		if field.Anonymous && name == "" {
+			fieldType := field.Type
+			for fieldType.Kind() == reflect.Ptr {
+				fieldType = fieldType.Elem()
+			}
+			if fieldType.Kind() == reflect.Struct {
+				builder.addStructFields(schema, fieldType)
+				continue
+			}
+		}
+		if name == "" {
+			name = field.Name
+		}
+		schema.Properties[name] = builder.schemaOf(field.Type)
+		if isRequiredField(field) {
+			schema.Required = append(schema.Required, name)
+		}
+	}
+}
+
+func isRequiredField(field reflect.StructField) bool {
+	for _, rule := range strings.Split(field.Tag.Get("binding"), ",") {
+		if rule == "required" {
+			return true
+		}
+	}
+	return false
+}
+
+// convert gin path to OpenAPI path, e.g. /user/:id/*path => /user/{id}/{path}
+func toOpenAPIPath(ginPath string) (string, []string) {
+	segments := strings.Split(ginPath, "/")
+	params := make([]string, 0)
+	for i, segment := range segments {
+		if strings.HasPrefix(segment, ":") || strings.HasPrefix(segment, "*") {
+			params = append(params, segment[1:])
+			segments[i] = "{" + segment[1:] + "}"
+		}
+	}
+	return strings.Join(segments, "/"), params
+}
+
+// Register routes of OpenAPI document and API explorer if they are enabled.
+// The document is also served when only API explorer is enabled, because the explorer depends on it.
+//
+// It won't be affected by `SetGlobalPreffix`.
+func InitOpenAPI(engine *gin.Engine) {
+	cfg := getOpenAPIConfig()
+	explorerEnabled := isAPIExplorerEnabled()
+	if !cfg.Enable && !explorerEnabled {
+		return
+	}
+
+	// documents of hosts are different, see `getOpenAPIDocument`
+	jsonData := map[*hostPattern][]byte{nil: marshalOpenAPIDocument(GetOpenAPIDocument())}
+	for _, pattern := range hostPatterns {
+		jsonData[pattern] = marshalOpenAPIDocument(getOpenAPIDocument(pattern))
+	}
+	yamlData := make(map[*hostPattern][]byte, len(jsonData))
+	for pattern, data := range jsonData {
+		var err error
+		if yamlData[pattern], err = jsonToYaml(data); err != nil {
+			panic(err)
+		}
+	}
+	engine.GET(cfg.Path+".json", func(c *gin.Context) {
+		pattern, _ := matchHostPattern(c.Request.Host)
+		c.Data(http.StatusOK, "application/json; charset=utf-8", jsonData[pattern])
+	})
+	engine.GET(cfg.Path+".yaml", func(c *gin.Context) {
+		pattern, _ := matchHostPattern(c.Request.Host)
+		c.Data(http.StatusOK, "application/yaml; charset=utf-8", yamlData[pattern])
+	})
+	if explorerEnabled {
+		initAPIExplorer(engine, cfg.UI.Path, cfg.Path+".json")
+	}
+}
+
+func marshalOpenAPIDocument(doc *OpenAPIDocument) []byte {
+	data, err := json.Marshal(doc)
+	if err != nil {
+		panic(err)
+	}
+	return data
+}
+
+// JSON is a subset of YAML, so key order is kept by parsing it as yaml node.
+func jsonToYaml(data []byte) ([]byte, error) {
+	var node yaml.Node
+	if err := yaml.Unmarshal(data, &node); err != nil {
+		return nil, err
+	}
+	resetYamlStyle(&node)
+	return yaml.Marshal(&node)
+}
+
+func resetYamlStyle(node *yaml.Node) {
+	node.Style = 0
+	for _, child := range node.Content {
+		resetYamlStyle(child)
+	}
+}
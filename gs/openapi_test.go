@@ -0,0 +1,77 @@
+package gs
+
+import (
+	"encoding/json"
+	"net/http"
+	"testing"
+
+	"github.com/dan-kuroto/gin-stronger/config"
+	"github.com/gin-gonic/gin"
+)
+
+type openAPITestQuery struct {
+	Page int    `form:"page" binding:"required"`
+	Sort string `form:"sort"`
+}
+
+type openAPITestUser struct {
+	Name  string   `json:"name" binding:"required"`
+	Tags  []string `json:"tags"`
+	Admin *bool    `json:"admin"`
+}
+
+func TestOpenAPIDocument(t *testing.T) {
+	setupTest(t, func(cfg *config.Configuration) {
+		cfg.OpenAPI.Enable = true
+		cfg.OpenAPI.Title = "test"
+	})
+	SetGlobalPreffix("/api")
+	rootRouter.Children = []Router{{Path: "/user", Deprecated: true, Children: []Router{
+		{Path: "", Method: GET, Name: "listUsers", Function: func(query openAPITestQuery) []openAPITestUser {
+			return nil
+		}},
+		{Path: "/:id", Method: PUT, Summary: "update user", Function: func(c *gin.Context, user *openAPITestUser) {}},
+	}}}
+	handler := newTestHandler(t)
+
+	w := doRequest(handler, testRequest{target: "/openapi.json"})
+	var doc OpenAPIDocument
+	if err := json.Unmarshal(w.Body.Bytes(), &doc); err != nil {
+		t.Fatalf("got %d %q: %v", w.Code, w.Body.String(), err)
+	}
+	if doc.Info.Title != "test" {
+		t.Errorf("got info %+v", doc.Info)
+	}
+
+	list := doc.Paths["/api/user"]["get"]
+	if list == nil || list.OperationId != "listUsers" || !list.Deprecated || len(list.Parameters) != 2 {
+		t.Fatalf("got list operation %+v", list)
+	}
+	if page := list.Parameters[0]; page.Name != "page" || page.In != "query" || !page.Required || page.Schema.Type != "integer" {
+		t.Errorf("got parameter %+v", page)
+	}
+	if schema := list.Responses["200"].Content[gin.MIMEJSON].Schema; schema.Type != "array" || schema.Items.Ref != "#/components/schemas/openAPITestUser" {
+		t.Errorf("got response schema %+v", schema)
+	}
+
+	update := doc.Paths["/api/user/{id}"]["put"]
+	if update == nil || update.Summary != "update user" || len(update.Parameters) != 1 || update.Parameters[0].In != "path" {
+		t.Fatalf("got update operation %+v", update)
+	}
+	if update.RequestBody == nil || update.RequestBody.Content[gin.MIMEJSON].Schema.Ref != "#/components/schemas/openAPITestUser" {
+		t.Errorf("got request body %+v", update.RequestBody)
+	}
+	if content := update.Responses["200"].Content; content != nil {
+		t.Errorf("got response content %+v", content)
+	}
+
+	user := doc.Components.Schemas["openAPITestUser"]
+	if user == nil || len(user.Required) != 1 || user.Required[0] != "name" ||
+		user.Properties["tags"].Items.Type != "string" || !user.Properties["admin"].Nullable {
+		t.Errorf("got user schema %+v", user)
+	}
+
+	if w = doRequest(handler, testRequest{target: "/openapi.yaml"}); w.Code != http.StatusOK {
+		t.Errorf("yaml: got %d", w.Code)
+	}
+}
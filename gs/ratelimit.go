@@ -0,0 +1,340 @@
+package gs
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/rs/zerolog/log"
+)
+
+type RateLimitAlgorithm string
+
+const (
+	// allow bursts up to `Limit`, and refill `Limit` tokens per `Period` smoothly
+	TokenBucket RateLimitAlgorithm = "token-bucket"
+	// at most `Limit` requests in any `Period`, estimated by counters of the current and previous windows
+	SlidingWindow RateLimitAlgorithm = "sliding-window"
+)
+
+// Rate limit of router, it is shared by all children of the router group.
+type RateLimit struct {
+	// used in metrics and store keys. default value is the full path of router
+	Name string `yaml:"name"`
+	// default value is token-bucket
+	Algorithm RateLimitAlgorithm `yaml:"algorithm"`
+	Limit     int                `yaml:"limit"`
+	Period    time.Duration      `yaml:"period"`
+	// How to identify clients, default value is ip.
+	//   - ip: client IP, see gin.Context.ClientIP
+	//   - header:{name}: value of request header (e.g. header:X-API-Key), or client IP if not set
+	//   - user: value of gin.AuthUserKey set by auth middleware (e.g. gin.BasicAuth), or client IP if not set
+	//   - context:{key}: value set by c.Set in middlewares, or client IP if not set
+	Key string `yaml:"key"`
+}
+
+type RateLimitResult struct {
+	Allowed   bool
+	Limit     int
+	Remaining int
+	// time until the limit is fully restored
+	Reset time.Duration
+	// time until the next request is allowed, 0 if allowed
+	RetryAfter time.Duration
+}
+
+// Storage of rate limit states, it should be safe for concurrent use.
+type RateLimitStore interface {
+	// Take a request of `key` and return whether it's allowed.
+	Take(ctx context.Context, key string, limit *RateLimit) (RateLimitResult, error)
+}
+
+var rateLimitStore RateLimitStore
+var rateLimitStoreOnce sync.Once
+
+// Replace the store decided by `rate-limit.store` config.
+func SetRateLimitStore(store RateLimitStore) {
+	rateLimitStoreOnce.Do(func() {})
+	rateLimitStore = store
+}
+
+func getRateLimitStore() RateLimitStore {
+	rateLimitStoreOnce.Do(func() {
+		if Config != nil && getRateLimitConfig().Store == "redis" {
+			cfg := getRedisConfig()
+			rateLimitStore = NewRedisRateLimitStore(cfg.Addr, cfg.Password, cfg.DB)
+		} else {
+			rateLimitStore = NewMemoryRateLimitStore()
+		}
+	})
+	return rateLimitStore
+}
+
+type rateLimitCounter struct {
+	Allowed atomic.Uint64
+	Limited atomic.Uint64
+	Errors  atomic.Uint64
+}
+
+var rateLimitCountersMutex sync.Mutex
+var rateLimitMetricsOnce sync.Once
+
+// limit name => counter
+var rateLimitCounters = make(map[string]*rateLimitCounter)
+
+func getRateLimitCounter(name string) *rateLimitCounter {
+	// metricsMutex must not be taken inside rateLimitCountersMutex, collectors are called in reverse order
+	rateLimitMetricsOnce.Do(func() {
+		AddMetrics("rateLimit", rateLimitMetrics)
+	})
+	rateLimitCountersMutex.Lock()
+	defer rateLimitCountersMutex.Unlock()
+	counter, ok := rateLimitCounters[name]
+	if !ok {
+		counter = &rateLimitCounter{}
+		rateLimitCounters[name] = counter
+	}
+	return counter
+}
+
+func rateLimitMetrics() any {
+	rateLimitCountersMutex.Lock()
+	defer rateLimitCountersMutex.Unlock()
+	metrics := make(map[string]map[string]uint64, len(rateLimitCounters))
+	for name, counter := range rateLimitCounters {
+		metrics[name] = map[string]uint64{
+			"allowed": counter.Allowed.Load(),
+			"limited": counter.Limited.Load(),
+			"errors":  counter.Errors.Load(),
+		}
+	}
+	return metrics
+}
+
+// Clients without the header or context value are identified by IP, so that they don't share a key.
+func getRateLimitClientKey(c *gin.Context, key string) string {
+	if name, ok := strings.CutPrefix(key, "header:"); ok {
+		if value := c.GetHeader(name); value != "" {
+			return "header:" + value
+		}
+		return "ip:" + c.ClientIP()
+	}
+	contextKey := ""
+	if key == "user" {
+		contextKey = gin.AuthUserKey
+	} else if name, ok := strings.CutPrefix(key, "context:"); ok {
+		contextKey = name
+	}
+	if contextKey != "" {
+		if value, ok := c.Get(contextKey); ok {
+			return "user:" + fmt.Sprint(value)
+		}
+	}
+	return "ip:" + c.ClientIP()
+}
+
+func (limit *RateLimit) validate() error {
+	if limit.Limit <= 0 || limit.Period <= 0 {
+		return errors.New("limit and period of rate limit must be positive")
+	}
+	if limit.Algorithm != "" && limit.Algorithm != TokenBucket && limit.Algorithm != SlidingWindow {
+		return fmt.Errorf("unknown rate limit algorithm %q", limit.Algorithm)
+	}
+	if !isValidClientKey(limit.Key) {
+		return fmt.Errorf("unknown rate limit key %q", limit.Key)
+	}
+	return nil
+}
+
+// see `RateLimit.Key`, empty key means the default one
+func isValidClientKey(key string) bool {
+	switch key {
+	case "", "ip", "user":
+		return true
+	}
+	for _, prefix := range []string{"header:", "context:"} {
+		if name, ok := strings.CutPrefix(key, prefix); ok {
+			return name != ""
+		}
+	}
+	return false
+}
+
+// Respond 429 if the client exceeds the limit. Store errors are logged and requests are allowed.
+func rateLimitMiddleware(limit RateLimit) gin.HandlerFunc {
+	if err := limit.validate(); err != nil {
+		panic(err)
+	}
+	if limit.Algorithm == "" {
+		limit.Algorithm = TokenBucket
+	}
+	if limit.Key == "" {
+		limit.Key = "ip"
+	}
+	counter := getRateLimitCounter(limit.Name)
+
+	return func(c *gin.Context) {
+		key := limit.Name + ":" + getRateLimitClientKey(c, limit.Key)
+		result, err := getRateLimitStore().Take(c.Request.Context(), key, &limit)
+		if err != nil {
+			counter.Errors.Add(1)
+			log.Err(err).Str("limit", limit.Name).Msg("rate limit store failed")
+			return
+		}
+		setRateLimitHeaders(c, &result)
+		if !result.Allowed {
+			counter.Limited.Add(1)
+			c.Header("Retry-After", strconv.Itoa(ceilSeconds(result.RetryAfter)))
+			AbortWithError(c, http.StatusTooManyRequests, "rate limit exceeded")
+			return
+		}
+		counter.Allowed.Add(1)
+	}
+}
+
+// Headers of IETF draft RateLimit header fields. If there are multiple limits,
+// the one with the least remaining is shown.
+func setRateLimitHeaders(c *gin.Context, result *RateLimitResult) {
+	header := c.Writer.Header()
+	if remaining := header.Get("RateLimit-Remaining"); remaining != "" {
+		if value, err := strconv.Atoi(remaining); err == nil && value <= result.Remaining {
+			return
+		}
+	}
+	header.Set("RateLimit-Limit", strconv.Itoa(result.Limit))
+	header.Set("RateLimit-Remaining", strconv.Itoa(result.Remaining))
+	header.Set("RateLimit-Reset", strconv.Itoa(ceilSeconds(result.Reset)))
+}
+
+func ceilSeconds(duration time.Duration) int {
+	return int(math.Ceil(duration.Seconds()))
+}
+
+// Result of token bucket, `tokens` is the number of tokens left after taking.
+func tokenBucketResult(limit *RateLimit, tokens float64, allowed bool) RateLimitResult {
+	rate := float64(limit.Limit) / float64(limit.Period)
+	result := RateLimitResult{
+		Allowed:   allowed,
+		Limit:     limit.Limit,
+		Remaining: int(math.Floor(tokens)),
+		Reset:     time.Duration((float64(limit.Limit) - tokens) / rate),
+	}
+	if !allowed {
+		result.RetryAfter = time.Duration((1 - tokens) / rate)
+	}
+	return result
+}
+
+// Result of sliding window, `elapsed` is the time since the current window starts,
+// and `current` includes the request if it's allowed.
+func slidingWindowResult(limit *RateLimit, elapsed time.Duration, previous, current float64, allowed bool) RateLimitResult {
+	period := float64(limit.Period)
+	weight := 1 - float64(elapsed)/period
+	estimated := previous*weight + current
+	result := RateLimitResult{
+		Allowed:   allowed,
+		Limit:     limit.Limit,
+		Remaining: max(0, int(math.Floor(float64(limit.Limit)-estimated))),
+		// previous window is out of range by then
+		Reset: limit.Period - elapsed,
+	}
+	if current > 0 {
+		result.Reset += limit.Period
+	}
+	if !allowed {
+		if current >= float64(limit.Limit) {
+			result.RetryAfter = limit.Period - elapsed
+		} else {
+			// estimated < limit when previous*(1-t/period) + current < limit
+			t := period * (1 - (float64(limit.Limit)-current)/previous)
+			result.RetryAfter = time.Duration(t) - elapsed
+		}
+	}
+	return result
+}
+
+// Default store, states are kept in memory of the process.
+type MemoryRateLimitStore struct {
+	mutex   sync.Mutex
+	entries map[string]*memoryRateLimitEntry
+	sweptAt time.Time
+}
+
+type memoryRateLimitEntry struct {
+	// token bucket: tokens and the time of last update
+	tokens    float64
+	updatedAt time.Time
+	// sliding window: counters and the start of current window
+	windowStart time.Time
+	previous    float64
+	current     float64
+
+	expiresAt time.Time
+}
+
+func NewMemoryRateLimitStore() *MemoryRateLimitStore {
+	return &MemoryRateLimitStore{entries: make(map[string]*memoryRateLimitEntry), sweptAt: time.Now()}
+}
+
+func (store *MemoryRateLimitStore) Take(_ context.Context, key string, limit *RateLimit) (RateLimitResult, error) {
+	store.mutex.Lock()
+	defer store.mutex.Unlock()
+
+	now := time.Now()
+	store.sweep(now)
+	entry, ok := store.entries[key]
+	if !ok {
+		entry = &memoryRateLimitEntry{tokens: float64(limit.Limit), updatedAt: now, windowStart: now.Truncate(limit.Period)}
+		store.entries[key] = entry
+	}
+
+	var result RateLimitResult
+	if limit.Algorithm == SlidingWindow {
+		windowStart := now.Truncate(limit.Period)
+		if windows := windowStart.Sub(entry.windowStart) / limit.Period; windows == 1 {
+			entry.previous, entry.current = entry.current, 0
+		} else if windows > 1 {
+			entry.previous, entry.current = 0, 0
+		}
+		entry.windowStart = windowStart
+		elapsed := now.Sub(windowStart)
+		allowed := entry.previous*(1-float64(elapsed)/float64(limit.Period))+entry.current < float64(limit.Limit)
+		if allowed {
+			entry.current++
+		}
+		result = slidingWindowResult(limit, elapsed, entry.previous, entry.current, allowed)
+		entry.expiresAt = windowStart.Add(2 * limit.Period)
+	} else {
+		rate := float64(limit.Limit) / float64(limit.Period)
+		entry.tokens = math.Min(float64(limit.Limit), entry.tokens+float64(now.Sub(entry.updatedAt))*rate)
+		entry.updatedAt = now
+		allowed := entry.tokens >= 1
+		if allowed {
+			entry.tokens--
+		}
+		result = tokenBucketResult(limit, entry.tokens, allowed)
+		entry.expiresAt = now.Add(result.Reset)
+	}
+	return result, nil
+}
+
+// Remove expired entries at most once per minute, their states are the same as new ones.
+func (store *MemoryRateLimitStore) sweep(now time.Time) {
+	if now.Sub(store.sweptAt) < time.Minute {
+		return
+	}
+	store.sweptAt = now
+	for key, entry := range store.entries {
+		if now.After(entry.expiresAt) {
+			delete(store.entries, key)
+		}
+	}
+}
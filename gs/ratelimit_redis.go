@@ -0,0 +1,102 @@
+package gs
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// KEYS[1]: bucket key; ARGV: limit, period (ms), now (ms)
+// returns {allowed, tokens left}
+var tokenBucketScript = redis.NewScript(`
+local limit = tonumber(ARGV[1])
+local period = tonumber(ARGV[2])
+local now = tonumber(ARGV[3])
+local state = redis.call('HMGET', KEYS[1], 'tokens', 'ts')
+local tokens = tonumber(state[1]) or limit
+local ts = tonumber(state[2]) or now
+tokens = math.min(limit, tokens + math.max(0, now - ts) * limit / period)
+local allowed = 0
+if tokens >= 1 then
+	tokens = tokens - 1
+	allowed = 1
+end
+redis.call('HSET', KEYS[1], 'tokens', tostring(tokens), 'ts', now)
+redis.call('PEXPIRE', KEYS[1], period)
+return {allowed, tostring(tokens)}
+`)
+
+// KEYS[1]: counter of current window, KEYS[2]: counter of previous window
+// ARGV: limit, period (ms), time since current window starts (ms)
+// returns {allowed, previous, current}
+var slidingWindowScript = redis.NewScript(`
+local limit = tonumber(ARGV[1])
+local period = tonumber(ARGV[2])
+local elapsed = tonumber(ARGV[3])
+local current = tonumber(redis.call('GET', KEYS[1])) or 0
+local previous = tonumber(redis.call('GET', KEYS[2])) or 0
+local allowed = 0
+if previous * (1 - elapsed / period) + current < limit then
+	current = redis.call('INCR', KEYS[1])
+	redis.call('PEXPIRE', KEYS[1], period * 2)
+	allowed = 1
+end
+return {allowed, previous, current}
+`)
+
+// Store rate limit states in Redis, so that the limit is shared by all instances.
+//
+// Each take is an atomic Lua script, and all keys of a client are hashed to the same slot of Redis Cluster.
+type RedisRateLimitStore struct {
+	client redis.Scripter
+}
+
+const redisKeyPrefix = "gs:rate-limit:"
+
+func NewRedisRateLimitStore(addr string, password string, db int) *RedisRateLimitStore {
+	return NewRedisRateLimitStoreWithClient(redis.NewClient(&redis.Options{Addr: addr, Password: password, DB: db}))
+}
+
+// Use an existing client, e.g. redis.ClusterClient or the client shared with other parts of app.
+func NewRedisRateLimitStoreWithClient(client redis.Scripter) *RedisRateLimitStore {
+	return &RedisRateLimitStore{client: client}
+}
+
+func (store *RedisRateLimitStore) Take(ctx context.Context, key string, limit *RateLimit) (RateLimitResult, error) {
+	nowMs := time.Now().UnixMilli()
+	periodMs := limit.Period.Milliseconds()
+	// hash tag keeps all keys of a client in the same slot of Redis Cluster
+	key = redisKeyPrefix + "{" + key + "}"
+
+	if limit.Algorithm == SlidingWindow {
+		window, elapsedMs := nowMs/periodMs, nowMs%periodMs
+		keys := []string{key + ":" + strconv.FormatInt(window, 10), key + ":" + strconv.FormatInt(window-1, 10)}
+		values, err := slidingWindowScript.Run(ctx, store.client, keys, limit.Limit, periodMs, elapsedMs).Int64Slice()
+		if err != nil {
+			return RateLimitResult{}, err
+		}
+		if len(values) != 3 {
+			return RateLimitResult{}, fmt.Errorf("unexpected redis reply %v", values)
+		}
+		elapsed := time.Duration(elapsedMs) * time.Millisecond
+		return slidingWindowResult(limit, elapsed, float64(values[1]), float64(values[2]), values[0] == 1), nil
+	}
+
+	reply, err := tokenBucketScript.Run(ctx, store.client, []string{key}, limit.Limit, periodMs, nowMs).Slice()
+	if err != nil {
+		return RateLimitResult{}, err
+	}
+	if len(reply) != 2 {
+		return RateLimitResult{}, fmt.Errorf("unexpected redis reply %v", reply)
+	}
+	allowed, _ := reply[0].(int64)
+	tokensText, _ := reply[1].(string)
+	tokens, err := strconv.ParseFloat(tokensText, 64)
+	if err != nil {
+		return RateLimitResult{}, fmt.Errorf("unexpected redis reply %v", reply)
+	}
+	return tokenBucketResult(limit, tokens, allowed == 1), nil
+}
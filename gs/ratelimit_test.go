@@ -0,0 +1,182 @@
+package gs
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestRateLimit(t *testing.T) {
+	for _, algorithm := range []RateLimitAlgorithm{TokenBucket, SlidingWindow} {
+		t.Run(string(algorithm), func(t *testing.T) {
+			setupTest(t, nil)
+			rootRouter.Children = []Router{{Path: "/api", Method: GET, Handlers: []gin.HandlerFunc{stringHandler("ok")},
+				RateLimit: &RateLimit{Algorithm: algorithm, Limit: 2, Period: time.Hour, Key: "header:X-API-Key"}}}
+			handler := newTestHandler(t)
+			request := func(apiKey string) *http.Response {
+				return doRequest(handler, testRequest{target: "/api", header: map[string]string{"X-API-Key": apiKey}}).Result()
+			}
+
+			for i, remaining := range []string{"1", "0"} {
+				if resp := request("a"); resp.StatusCode != http.StatusOK || resp.Header.Get("RateLimit-Remaining") != remaining {
+					t.Fatalf("request %d: got %d remaining %q", i, resp.StatusCode, resp.Header.Get("RateLimit-Remaining"))
+				}
+			}
+			resp := request("a")
+			if resp.StatusCode != http.StatusTooManyRequests || resp.Header.Get("Retry-After") == "" {
+				t.Errorf("exceeded: got %d Retry-After %q", resp.StatusCode, resp.Header.Get("Retry-After"))
+			}
+			if resp := request("b"); resp.StatusCode != http.StatusOK {
+				t.Errorf("other key: got %d", resp.StatusCode)
+			}
+			// clients without the header are identified by IP, rather than sharing one key
+			request("")
+			request("")
+			if resp := request(""); resp.StatusCode != http.StatusTooManyRequests {
+				t.Errorf("without header: got %d", resp.StatusCode)
+			}
+		})
+	}
+}
+
+func TestRateLimitValidation(t *testing.T) {
+	handlers := []gin.HandlerFunc{stringHandler("")}
+	router := Router{Path: "", Children: []Router{
+		{Path: "/a", Method: GET, Handlers: handlers, RateLimit: &RateLimit{Limit: 0, Period: time.Second}},
+		{Path: "/b", Method: GET, Handlers: handlers, RateLimit: &RateLimit{Limit: 1, Period: time.Second, Algorithm: "leaky"}},
+		{Path: "/c", Method: GET, Handlers: handlers, RateLimit: &RateLimit{Limit: 1, Period: time.Second, Key: "header:"}},
+		{Path: "/d", Method: GET, Handlers: handlers, RateLimit: &RateLimit{Limit: 1, Period: time.Second, Key: "context:uid"}},
+	}}
+	err := ValidateRouter(&router)
+	if errs, ok := err.(RouterErrors); !ok || len(errs) != 3 {
+		t.Errorf("got %v, want 3 invalid rate limits", err)
+	}
+}
+
+// The first counter registers metrics while admin collects them.
+func TestRateLimitMetricsLockOrder(t *testing.T) {
+	// keep admin collecting, so that the counter is created in the meantime
+	AddMetrics("slow", func() any {
+		time.Sleep(time.Millisecond)
+		return nil
+	})
+	t.Cleanup(func() {
+		// the mutex is never released after deadlock
+		if metricsMutex.TryLock() {
+			delete(metricsCollectors, "slow")
+			metricsMutex.Unlock()
+		}
+	})
+	for i := 0; i < 20; i++ {
+		rateLimitCounters = make(map[string]*rateLimitCounter)
+		rateLimitMetricsOnce = sync.Once{}
+		c, _ := gin.CreateTestContext(httptest.NewRecorder())
+
+		done := make(chan struct{})
+		go func() {
+			var wg sync.WaitGroup
+			wg.Add(2)
+			go func() {
+				defer wg.Done()
+				getRateLimitCounter("api").Allowed.Add(1)
+			}()
+			go func() {
+				defer wg.Done()
+				adminMetrics(c)
+			}()
+			wg.Wait()
+			close(done)
+		}()
+		select {
+		case <-done:
+		case <-time.After(time.Second):
+			t.Fatal("deadlock between rate limit counters and metrics")
+		}
+	}
+}
+
+// Fake Redis which records EVAL commands and replies {1, 0, 1} to them. Scripts are never cached,
+// and other commands (e.g. HELLO) are unknown, so that client falls back to RESP2 and EVAL.
+func startFakeRedis(t *testing.T, commands chan<- []string) string {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { listener.Close() })
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			go func() {
+				defer conn.Close()
+				reader := bufio.NewReader(conn)
+				for {
+					var count int
+					if _, err := fmt.Fscanf(reader, "*%d\r\n", &count); err != nil {
+						return
+					}
+					command := make([]string, count)
+					for i := range command {
+						var size int
+						if _, err := fmt.Fscanf(reader, "$%d\r\n", &size); err != nil {
+							return
+						}
+						data := make([]byte, size+2)
+						if _, err := io.ReadFull(reader, data); err != nil {
+							return
+						}
+						command[i] = string(data[:size])
+					}
+					switch strings.ToUpper(command[0]) {
+					case "EVAL":
+						commands <- command
+						io.WriteString(conn, "*3\r\n:1\r\n:0\r\n:1\r\n")
+					case "EVALSHA":
+						io.WriteString(conn, "-NOSCRIPT No matching script\r\n")
+					default:
+						io.WriteString(conn, "-ERR unknown command\r\n")
+					}
+				}
+			}()
+		}
+	}()
+	return listener.Addr().String()
+}
+
+func TestRedisRateLimitStoreKeys(t *testing.T) {
+	commands := make(chan []string, 1)
+	store := NewRedisRateLimitStore(startFakeRedis(t, commands), "", 0)
+	limit := &RateLimit{Algorithm: SlidingWindow, Limit: 10, Period: time.Minute}
+	result, err := store.Take(context.Background(), "api:ip:1.2.3.4", limit)
+	if err != nil || !result.Allowed {
+		t.Fatalf("got %+v %v", result, err)
+	}
+
+	command := <-commands
+	if command[2] != "2" {
+		t.Fatalf("got command %q, want EVAL with 2 keys", command[:3])
+	}
+	// keys of both windows must be given, and hashed to the same slot
+	const prefix = "gs:rate-limit:{api:ip:1.2.3.4}:"
+	current, err1 := strconv.ParseInt(strings.TrimPrefix(command[3], prefix), 10, 64)
+	previous, err2 := strconv.ParseInt(strings.TrimPrefix(command[4], prefix), 10, 64)
+	if err1 != nil || err2 != nil || current != previous+1 {
+		t.Errorf("got keys %q, want counters of current and previous windows", command[3:5])
+	}
+	if strings.Contains(command[1], "..") {
+		t.Error("script builds keys which are not given by KEYS")
+	}
+}
@@ -0,0 +1,28 @@
+package gs
+
+import (
+	"github.com/gin-gonic/gin"
+)
+
+// standard error response of the errors generated by gs, e.g. 410 of sunset routes
+type ErrorResponse struct {
+	Status  int    `json:"status"`
+	Message string `json:"message"`
+}
+
+type ErrorResponder func(c *gin.Context, status int, message string)
+
+var errorResponder ErrorResponder = func(c *gin.Context, status int, message string) {
+	c.AbortWithStatusJSON(status, ErrorResponse{Status: status, Message: message})
+}
+
+// Customize the response of the errors generated by gs.
+func SetErrorResponder(responder ErrorResponder) {
+	errorResponder = responder
+}
+
+// Abort with the standard error response.
+func AbortWithError(c *gin.Context, status int, message string) {
+	errorResponder(c, status, message)
+	c.Abort()
+}
@@ -0,0 +1,186 @@
+package gs
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/dan-kuroto/gin-stronger/config"
+	"github.com/gin-gonic/gin"
+	"gopkg.in/yaml.v3"
+)
+
+var registeredHandlers = make(map[string]gin.HandlerFunc)
+var registeredMiddleWares = make(map[string]gin.HandlerFunc)
+
+// Register handler by name, so that it can be referenced in route file.
+// Use `PackageHandlers` to register functions which are not gin.HandlerFunc.
+func RegisterHandler(name string, handler gin.HandlerFunc) {
+	if _, ok := registeredHandlers[name]; ok {
+		panic("handler " + name + " is already registered")
+	}
+	registeredHandlers[name] = handler
+}
+
+// Register middleware by name, so that it can be referenced in route file.
+func RegisterMiddleware(name string, middleware gin.HandlerFunc) {
+	if _, ok := registeredMiddleWares[name]; ok {
+		panic("middleware " + name + " is already registered")
+	}
+	registeredMiddleWares[name] = middleware
+}
+
+// Router in route file, handlers and middlewares are referenced by registered names.
+//
+// e.g.
+//
+//	# routes.yml
+//	- path: /user
+//	  middlewares: [auth]
+//	  children:
+//	    - path: /:id
+//	      methods: [GET, HEAD]
+//	      handlers: [getUser]
+//	      name: user.get
+type routeFileRouter struct {
+	Path                  string             `yaml:"path"`
+	Methods               []string           `yaml:"methods"`
+	MiddleWares           []string           `yaml:"middlewares"`
+	SkipParentMiddleWares bool               `yaml:"skip-parent-middlewares"`
+	Handlers              []string           `yaml:"handlers"`
+	Children              []routeFileRouter  `yaml:"children"`
+	Summary               string             `yaml:"summary"`
+	Name                  string             `yaml:"name"`
+	Deprecated            bool               `yaml:"deprecated"`
+	DeprecatedAt          time.Time          `yaml:"deprecated-at"`
+	Sunset                time.Time          `yaml:"sunset"`
+	DeprecationLink       string             `yaml:"deprecation-link"`
+	GoneAfterSunset       bool               `yaml:"gone-after-sunset"`
+	Feature               string             `yaml:"feature"`
+	Host                  string             `yaml:"host"`
+	Timeout               time.Duration      `yaml:"timeout"`
+	RateLimit             *RateLimit         `yaml:"rate-limit"`
+	CORS                  *config.CORSPolicy `yaml:"cors"`
+	MaxBodySize           config.ByteSize    `yaml:"max-body-size"`
+	Compression           *bool              `yaml:"compression"` // nil inherits from parent
+	ETag                  bool               `yaml:"etag"`
+	Idempotency           *Idempotency       `yaml:"idempotency"`
+}
+
+// Load routers from routes-{env}.yml, or routes.yml if it does not exist.
+// (`env` is given by application.yml)
+//
+// Routers are added to root router like controllers. It is no-op if neither file exists.
+func InitRouteFile() error {
+	const baseName = "routes"
+	fpaths := []string{baseName + ".yml"}
+	if Config.GetActiveEnv() != "" {
+		fpaths = append([]string{baseName + "-" + Config.GetActiveEnv() + ".yml"}, fpaths...)
+	}
+	for _, fpath := range fpaths {
+		data, err := os.ReadFile(fpath)
+		if errors.Is(err, fs.ErrNotExist) {
+			continue
+		} else if err != nil {
+			return err
+		}
+
+		var routers []routeFileRouter
+		if err := yaml.Unmarshal(data, &routers); err != nil {
+			return fmt.Errorf("parse %s failed: %w", fpath, err)
+		}
+		builder := routeFileBuilder{fpath: fpath}
+		children := builder.buildRouters(routers, nil)
+		if len(builder.errs) != 0 {
+			return builder.errs
+		}
+		rootRouter.Children = append(rootRouter.Children, Router{Path: "", Children: children, controller: fpath})
+		return nil
+	}
+	return nil
+}
+
+type routeFileBuilder struct {
+	fpath string
+	errs  RouterErrors
+}
+
+func (builder *routeFileBuilder) buildRouters(routers []routeFileRouter, paths []string) []Router {
+	result := make([]Router, 0, len(routers))
+	for _, router := range routers {
+		result = append(result, builder.buildRouter(&router, append(slices.Clip(paths), router.Path)))
+	}
+	return result
+}
+
+func (builder *routeFileBuilder) buildRouter(router *routeFileRouter, paths []string) Router {
+	gsRouter := Router{
+		Path:                  router.Path,
+		SkipParentMiddleWares: router.SkipParentMiddleWares,
+		Summary:               router.Summary,
+		Name:                  router.Name,
+		Deprecated:            router.Deprecated,
+		DeprecatedAt:          router.DeprecatedAt,
+		Sunset:                router.Sunset,
+		DeprecationLink:       router.DeprecationLink,
+		GoneAfterSunset:       router.GoneAfterSunset,
+		Feature:               router.Feature,
+		Host:                  router.Host,
+		Timeout:               router.Timeout,
+		RateLimit:             router.RateLimit,
+		CORS:                  router.CORS,
+		MaxBodySize:           int64(router.MaxBodySize),
+		ETag:                  router.ETag,
+		Idempotency:           router.Idempotency,
+	}
+	if router.Compression != nil {
+		gsRouter.Compression = CompressionOff
+		if *router.Compression {
+			gsRouter.Compression = CompressionOn
+		}
+	}
+	for _, name := range router.Methods {
+		method, ok := parseHttpMethod(name)
+		if !ok {
+			builder.addError(paths, "unknown method %s", name)
+		}
+		gsRouter.Method |= method
+	}
+	for _, name := range router.MiddleWares {
+		middleware, ok := registeredMiddleWares[name]
+		if !ok {
+			builder.addError(paths, "unknown middleware %s", name)
+		}
+		gsRouter.MiddleWares = append(gsRouter.MiddleWares, middleware)
+	}
+	for _, name := range router.Handlers {
+		handler, ok := registeredHandlers[name]
+		if !ok {
+			builder.addError(paths, "unknown handler %s", name)
+		}
+		gsRouter.Handlers = append(gsRouter.Handlers, handler)
+	}
+	gsRouter.Children = builder.buildRouters(router.Children, paths)
+	return gsRouter
+}
+
+func (builder *routeFileBuilder) addError(paths []string, format string, args ...any) {
+	builder.errs = append(builder.errs, &RouterError{
+		Controller: builder.fpath,
+		Paths:      paths,
+		Message:    fmt.Sprintf(format, args...),
+	})
+}
+
+func parseHttpMethod(name string) (HttpMethod, bool) {
+	for _, item := range httpMethodNames {
+		if item.name == strings.ToUpper(name) {
+			return item.method, true
+		}
+	}
+	return 0, false
+}
@@ -0,0 +1,109 @@
+package gs
+
+import (
+	"errors"
+	"net/http"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/dan-kuroto/gin-stronger/config"
+	"github.com/gin-gonic/gin"
+)
+
+// Write route files to a temporary working directory, registered handlers are reset after the test.
+func setupRouteFiles(t *testing.T, files map[string]string) {
+	t.Helper()
+	dir := t.TempDir()
+	for name, content := range files {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	wd, err := os.Getwd()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Chdir(dir); err != nil {
+		t.Fatal(err)
+	}
+	handlers, middleWares := registeredHandlers, registeredMiddleWares
+	registeredHandlers, registeredMiddleWares = make(map[string]gin.HandlerFunc), make(map[string]gin.HandlerFunc)
+	t.Cleanup(func() {
+		os.Chdir(wd)
+		registeredHandlers, registeredMiddleWares = handlers, middleWares
+	})
+}
+
+func TestRouteFile(t *testing.T) {
+	setupTest(t, func(cfg *config.Configuration) {
+		cfg.Env.Active = "dev"
+	})
+	setupRouteFiles(t, map[string]string{
+		"routes.yml": `
+- path: /ignored
+  handlers: [getUser]
+`,
+		"routes-dev.yml": `
+- path: /user
+  middlewares: [trace]
+  children:
+    - path: /:id
+      methods: [GET, head]
+      handlers: [getUser]
+      name: user.get
+    - path: /public
+      skip-parent-middlewares: true
+      handlers: [getUser]
+`,
+	})
+	RegisterMiddleware("trace", traceMiddleware("trace"))
+	RegisterHandler("getUser", func(c *gin.Context) {
+		c.String(http.StatusOK, "user")
+	})
+	if err := InitRouteFile(); err != nil {
+		t.Fatal(err)
+	}
+	handler := newTestHandler(t)
+
+	tests := []struct {
+		method, path string
+		status       int
+		trace        string
+	}{
+		{http.MethodGet, "/user/1", http.StatusOK, "trace"},
+		{http.MethodHead, "/user/1", http.StatusOK, "trace"},
+		{http.MethodGet, "/user/public", http.StatusOK, ""},
+		// routes.yml is ignored if routes-dev.yml exists
+		{http.MethodGet, "/ignored", http.StatusNotFound, ""},
+	}
+	for _, test := range tests {
+		w := doRequest(handler, testRequest{method: test.method, target: test.path})
+		if w.Code != test.status || w.Header().Get("X-Trace") != test.trace {
+			t.Errorf("%s %s: got %d, X-Trace %q", test.method, test.path, w.Code, w.Header().Get("X-Trace"))
+		}
+	}
+	if url, err := URLFor("user.get", 1); err != nil || url != "/user/1" {
+		t.Errorf("got %q, %v", url, err)
+	}
+}
+
+func TestRouteFileErrors(t *testing.T) {
+	setupTest(t, nil)
+	setupRouteFiles(t, map[string]string{"routes.yml": `
+- path: /user
+  middlewares: [unknown]
+  children:
+    - path: /:id
+      methods: [FETCH]
+      handlers: [missing]
+`})
+
+	var errs RouterErrors
+	if err := InitRouteFile(); !errors.As(err, &errs) || len(errs) != 3 {
+		t.Fatalf("got %v", err)
+	}
+	if errs[0].Controller != "routes.yml" || len(errs[2].Paths) != 2 || errs[2].Paths[1] != "/:id" {
+		t.Errorf("got %v", errs)
+	}
+}
@@ -2,6 +2,11 @@ package gs
 
 import (
 	"net/http"
+	"path"
+	"reflect"
+	"slices"
+	"strings"
+	"time"
 
 	"github.com/dan-kuroto/gin-stronger/config"
 	"github.com/gin-gonic/gin"
@@ -24,19 +29,115 @@ const (
 	Any = GET | HEAD | POST | PUT | PATCH | DELETE | CONNECT | OPTIONS | TRACE
 )
 
+var httpMethodNames = []struct {
+	method HttpMethod
+	name   string
+}{
+	{GET, http.MethodGet},
+	{HEAD, http.MethodHead},
+	{POST, http.MethodPost},
+	{PUT, http.MethodPut},
+	{PATCH, http.MethodPatch},
+	{DELETE, http.MethodDelete},
+	{CONNECT, http.MethodConnect},
+	{OPTIONS, http.MethodOptions},
+	{TRACE, http.MethodTrace},
+}
+
+// Get http method names of the bitmask. 0 is regarded as GET.
+func (method HttpMethod) names() []string {
+	if method == 0 {
+		return []string{http.MethodGet}
+	}
+	names := make([]string, 0, len(httpMethodNames))
+	for _, item := range httpMethodNames {
+		if method&item.method != 0 {
+			names = append(names, item.name)
+		}
+	}
+	return names
+}
+
 var rootRouter = Router{Path: ""}
 var staticMapFunc StaticMapFunc
 
+var noRouteHandlers = make([]gin.HandlerFunc, 0)
+
 type Router struct {
 	Path string
 	// invalid for router group. default value is gs.GET
 	Method HttpMethod
-	// valid for router group
+	// Middlewares of router group are applied to all of its children.
+	// For router which is not a group, they are applied before `Handlers`.
 	MiddleWares []gin.HandlerFunc
+	// If true, middlewares inherited from parent router groups (including those added by
+	// `AddGlobalMiddleware`) are not applied to this router and its children.
+	SkipParentMiddleWares bool
 	// invalid for router group
 	Handlers []gin.HandlerFunc
+	// Invalid for router group. It is packaged by `PackageHandlers` and called after `Handlers`,
+	// and its name, request and result types are used by OpenAPI document and route listing.
+	Function any
 	// if len(Children) != 0, it is a router group
 	Children []Router
+	// invalid for router group. used by OpenAPI document
+	Summary string
+	// invalid for router group. it should be unique, see `URLFor`
+	Name string
+
+	// Deprecation settings, they are inherited by children.
+	// Deprecation, Sunset and Link headers are added to responses, and each call is logged.
+	Deprecated bool
+	// the date since when router is deprecated, it implies `Deprecated`. Deprecation header
+	// (RFC 9745) requires the date, so it is not added if only `Deprecated` is set
+	DeprecatedAt time.Time
+	Sunset       time.Time
+	// url of the deprecation document, added to Link header
+	DeprecationLink string
+	// respond 410 Gone after `Sunset`
+	GoneAfterSunset bool
+
+	// Feature key toggled by `features` config or `SetFeature`, it is inherited by children.
+	// Router is available only if all features of itself and its parents are enabled.
+	Feature string
+
+	// Deadline of request context, it is inherited by children. Default value is `server.request-timeout`,
+	// and negative value disables it. See `timeoutMiddleware` for details.
+	Timeout time.Duration
+
+	// Rate limit shared by the router and its children. It's applied after `MiddleWares` of the router,
+	// so that clients can be identified by auth middlewares, and it's skipped by `SkipParentMiddleWares` of children.
+	RateLimit *RateLimit
+
+	// Replay responses of unsafe requests with the same Idempotency-Key, see `idempotencyMiddleware`.
+	// It's applied after `RateLimit`, and it's skipped by `SkipParentMiddleWares` of children.
+	Idempotency *Idempotency
+
+	// CORS policy of the router and its children, it overrides `cors` config.
+	CORS *config.CORSPolicy
+
+	// Max size of request body in bytes, it is inherited by children. Default value is `server.max-body-size`,
+	// and negative value means unlimited.
+	MaxBodySize int64
+
+	// Compress responses of the router and its children, see `compressionMiddleware`.
+	Compression CompressionMode
+
+	// Handle conditional requests of GET handlers packaged by `PackageHandlers` for the router and
+	// its children, see `conditionalMiddleware`.
+	ETag bool
+
+	// Host pattern, router and its children are served only for requests whose Host header matches.
+	// e.g. api.example.com, *.example.com or :tenant.example.com, the first label captured by wildcard
+	// is available as path param (named "subdomain" for *). It can't be nested.
+	Host string
+
+	// type name of the controller which provides this router, set by `UseController`
+	controller string
+	// name of `Function` if it can't be got by reflection, e.g. method values of `NewConventionRouter`
+	functionName string
+	// whether it is served by admin listener
+	admin bool
 }
 
 type ginEngineOrGroup interface {
@@ -51,73 +152,176 @@ type ginEngineOrGroup interface {
 	Group(relativePath string, handlers ...gin.HandlerFunc) *gin.RouterGroup
 	Handle(httpMethod, relativePath string, handlers ...gin.HandlerFunc) gin.IRoutes
 	Use(middleware ...gin.HandlerFunc) gin.IRoutes
+	BasePath() string
 }
 
 type Controller interface {
 	GetRouter() Router
 }
 
-func handleRouter(router ginEngineOrGroup, gsRouter *Router) {
-	if gsRouter.Method == Any {
-		router.Any(gsRouter.Path, gsRouter.Handlers...)
-	} else if gsRouter.Method == 0 {
-		router.GET(gsRouter.Path, gsRouter.Handlers...)
-	} else {
-		if gsRouter.Method&GET != 0 {
-			router.Handle(http.MethodGet, gsRouter.Path, gsRouter.Handlers...)
+func handleRouter(router ginEngineOrGroup, relativePath string, method HttpMethod, handlers []gin.HandlerFunc) {
+	for _, name := range method.names() {
+		router.Handle(name, relativePath, handlers...)
+	}
+}
+
+// information inherited from parent router groups
+type routerScope struct {
+	admin       bool
+	controller  string
+	middleWares []gin.HandlerFunc
+	deprecation deprecation
+	features    []string
+	timeout     time.Duration
+	maxBodySize int64
+	compression CompressionMode
+	etag        bool
+	// engine of `AddRouter`, routers bound to host are registered to engines created for it
+	engine *gin.Engine
+	host   *hostPattern
+	cors   *corsPolicy
+	// shared by all routers of `AddRouter`
+	preflights *corsPreflights
+}
+
+// Register router tree to gin engine or group.
+// It panics with `RouterErrors` found by `ValidateRouter` if the tree is invalid.
+func AddRouter(router ginEngineOrGroup, gsRouter *Router) {
+	if err := validateRouter(router.BasePath(), gsRouter); err != nil {
+		panic(err)
+	}
+	engine, _ := router.(*gin.Engine)
+	preflights := &corsPreflights{registered: make(map[string]bool)}
+	addRouter(router, gsRouter, routerScope{engine: engine, preflights: preflights})
+	preflights.register()
+}
+
+func addRouter(router ginEngineOrGroup, gsRouter *Router, scope routerScope) {
+	if gsRouter.controller != "" {
+		scope.controller = gsRouter.controller
+	}
+	if gsRouter.admin {
+		scope.admin = true
+	}
+	if gsRouter.Host != "" {
+		if scope.engine == nil {
+			panic("router bound to host must be added to gin.Engine")
 		}
-		if gsRouter.Method&HEAD != 0 {
-			router.Handle(http.MethodHead, gsRouter.Path, gsRouter.Handlers...)
+		pattern, err := getHostPattern(gsRouter.Host)
+		if err != nil {
+			panic(err)
 		}
-		if gsRouter.Method&POST != 0 {
-			router.Handle(http.MethodPost, gsRouter.Path, gsRouter.Handlers...)
+		// registered to engine of the host, see `newHostHandler`
+		router = getHostEngine(scope.engine, pattern).Group(router.BasePath())
+		scope.host = pattern
+	}
+	if gsRouter.SkipParentMiddleWares {
+		scope.middleWares = nil
+	}
+	// clip to avoid modifying the slice shared with siblings
+	scope.middleWares = append(slices.Clip(scope.middleWares), gsRouter.MiddleWares...)
+	if gsRouter.RateLimit != nil {
+		limit := *gsRouter.RateLimit
+		if limit.Name == "" {
+			limit.Name = scope.host.displayPath(joinPaths(router.BasePath(), gsRouter.Path))
 		}
-		if gsRouter.Method&PUT != 0 {
-			router.Handle(http.MethodPut, gsRouter.Path, gsRouter.Handlers...)
+		scope.middleWares = append(scope.middleWares, rateLimitMiddleware(limit))
+	}
+	if gsRouter.Idempotency != nil {
+		scope.middleWares = append(scope.middleWares, idempotencyMiddleware(*gsRouter.Idempotency))
+	}
+
+	scope.deprecation = scope.deprecation.inherit(gsRouter)
+	if gsRouter.CORS != nil {
+		policy, err := newCORSPolicy(gsRouter.CORS)
+		if err != nil {
+			panic(err)
 		}
-		if gsRouter.Method&PATCH != 0 {
-			router.Handle(http.MethodPatch, gsRouter.Path, gsRouter.Handlers...)
+		scope.cors = policy
+	}
+	if gsRouter.Timeout != 0 {
+		scope.timeout = gsRouter.Timeout
+	}
+	if gsRouter.MaxBodySize != 0 {
+		scope.maxBodySize = gsRouter.MaxBodySize
+	}
+	if gsRouter.Compression != CompressionInherit {
+		scope.compression = gsRouter.Compression
+	}
+	scope.etag = scope.etag || gsRouter.ETag
+	if gsRouter.Feature != "" {
+		scope.features = append(slices.Clip(scope.features), gsRouter.Feature)
+	}
+
+	if len(gsRouter.Children) == 0 {
+		handlers := make([]gin.HandlerFunc, 0, len(scope.middleWares)+len(gsRouter.Handlers)+7)
+		if len(scope.features) != 0 {
+			handlers = append(handlers, featureMiddleware(scope.features))
 		}
-		if gsRouter.Method&DELETE != 0 {
-			router.Handle(http.MethodDelete, gsRouter.Path, gsRouter.Handlers...)
+		if scope.deprecation.enabled() {
+			handlers = append(handlers, deprecationMiddleware(scope.deprecation))
 		}
-		if gsRouter.Method&CONNECT != 0 {
-			router.Handle(http.MethodConnect, gsRouter.Path, gsRouter.Handlers...)
+		if timeout := scope.getTimeout(); timeout > 0 {
+			handlers = append(handlers, timeoutMiddleware(timeout))
 		}
+		if maxBodySize := scope.getMaxBodySize(); maxBodySize > 0 {
+			handlers = append(handlers, bodyLimitMiddleware(maxBodySize))
+		}
+		if scope.isCompressionEnabled() {
+			handlers = append(handlers, compressionMiddleware())
+		}
+		// method 0 is regarded as GET
+		if scope.etag && (gsRouter.Method == 0 || gsRouter.Method&(GET|HEAD) != 0) {
+			handlers = append(handlers, conditionalMiddleware)
+		}
+		handlers = append(handlers, scope.middleWares...)
+		handlers = append(handlers, gsRouter.Handlers...)
+		if gsRouter.Function != nil {
+			handlers = append(handlers, PackageHandlers(gsRouter.Function)...)
+		}
+		handleRouter(router, gsRouter.Path, gsRouter.Method, handlers)
 		if gsRouter.Method&OPTIONS != 0 {
-			router.Handle(http.MethodOptions, gsRouter.Path, gsRouter.Handlers...)
+			scope.preflights.markRegistered(scope.host, joinPaths(router.BasePath(), gsRouter.Path))
 		}
-		if gsRouter.Method&TRACE != 0 {
-			router.Handle(http.MethodTrace, gsRouter.Path, gsRouter.Handlers...)
+		if scope.cors != nil {
+			scope.preflights.add(router, gsRouter, scope.host, scope.cors)
 		}
-	}
-}
-
-func AddRouter(router ginEngineOrGroup, gsRouter *Router) {
-	if len(gsRouter.Children) == 0 {
-		handleRouter(router, gsRouter)
+		recordRoute(router, gsRouter, scope)
 	} else {
+		// middlewares are combined by gs rather than `group.Use`, so that children can skip them
 		group := router.Group(gsRouter.Path)
-		if len(gsRouter.MiddleWares) != 0 {
-			group.Use(gsRouter.MiddleWares...)
-		}
 		for _, subRouter := range gsRouter.Children {
-			AddRouter(group, &subRouter)
+			addRouter(group, &subRouter, scope)
 		}
 	}
 }
 
-func InitStatic(engine *gin.Engine) {
-	if staticMapFunc == nil {
-		return
+// same as the path joining of gin.RouterGroup
+func joinPaths(absolutePath, relativePath string) string {
+	if relativePath == "" {
+		return absolutePath
 	}
-	for urlPath, filePath := range staticMapFunc() {
-		engine.Static(urlPath, filePath)
+
+	finalPath := path.Join(absolutePath, relativePath)
+	if strings.HasSuffix(relativePath, "/") && !strings.HasSuffix(finalPath, "/") {
+		return finalPath + "/"
+	}
+	return finalPath
+}
+
+// Handlers called in order when no route matches, until one of them aborts.
+func addNoRouteHandler(handler gin.HandlerFunc) {
+	noRouteHandlers = append(noRouteHandlers, handler)
+}
+
+func initNoRoute(engine *gin.Engine) {
+	if len(noRouteHandlers) != 0 {
+		engine.NoRoute(noRouteHandlers...)
 	}
 }
 
 // Set global URL preffix.
-// Has no effect on the prefix of `RegisterStatic`.
+// Has no effect on static files unless `StaticMount.UseGlobalPreffix` is set.
 func SetGlobalPreffix(preffix string) {
 	rootRouter.Path = preffix
 }
@@ -126,16 +330,36 @@ func AddGlobalMiddleware(middlewares ...gin.HandlerFunc) {
 	rootRouter.MiddleWares = append(rootRouter.MiddleWares, middlewares...)
 }
 
+// name of `Function`, e.g. controller.GetUser
+func (router *Router) getFunctionName() string {
+	if router.functionName != "" {
+		return router.functionName
+	}
+	return getFunctionName(router.Function)
+}
+
+// e.g. controller.UserController
+func getTypeName(value any) string {
+	return reflect.TypeOf(value).String()
+}
+
 func UseController(controller Controller) {
-	rootRouter.Children = append(rootRouter.Children, controller.GetRouter())
+	router := controller.GetRouter()
+	if router.controller == "" {
+		router.controller = getTypeName(controller)
+	}
+	rootRouter.Children = append(rootRouter.Children, router)
 }
 
-// Register static files. `url2path` is the mapping of url to file path
-// (directory path is supported).
-//
-// It won't be affected by `SetGlobalPreffix`.
-func SetStatic(getter StaticMapFunc) {
-	staticMapFunc = getter
+func logRouterErrors(err error) {
+	routerErrs, ok := err.(RouterErrors)
+	if !ok {
+		log.Err(err).Send()
+		return
+	}
+	for _, routerErr := range routerErrs {
+		log.Error().Str("controller", routerErr.Controller).Strs("paths", routerErr.Paths).Msg(routerErr.Message)
+	}
 }
 
 func RunApp[T config.IConfiguration](config T) {
@@ -143,21 +367,72 @@ func RunApp[T config.IConfiguration](config T) {
 	if err := InitConfig(config); err != nil {
 		log.Err(err).Msg("InitConfig failed")
 	}
-	if onConfigInitialized != nil {
-		onConfigInitialized()
-	}
+	mustRunHooks(PhaseConfigLoaded)
 	InitIdGenerators()
 
 	if Config.GetGinRelease() {
 		gin.SetMode(gin.ReleaseMode)
 	}
 
+	mustRunHooks(PhaseBeforeRoutes)
+	if err := InitRouteFile(); err != nil {
+		logRouterErrors(err)
+		log.Fatal().Msg("load route file failed")
+	}
+	// root router without controllers is not a valid group
+	hasRouters := len(rootRouter.Children) != 0
+	if hasRouters {
+		if err := ValidateRouter(&rootRouter); err != nil {
+			logRouterErrors(err)
+			log.Fatal().Msg("router validation failed")
+		}
+	}
+
+	if _, err := getGlobalCORSPolicy(); err != nil {
+		log.Fatal().Err(err).Msg("invalid cors config")
+	}
+	engine = newEngine()
+
+	var adminEngine *gin.Engine
+	adminAddr := getAdminAddr()
+	if adminAddr != "" {
+		adminEngine = InitAdmin()
+	}
+	if getRoutesConfig().Print {
+		PrintRoutes()
+	}
+	mustRunHooks(PhaseEngineBuilt)
+
+	server, err := newServer(Config.GetGinAddr(), newPublicHandler(engine))
+	if err != nil {
+		log.Fatal().Err(err).Msg("create server failed")
+	}
+	servers := []*http.Server{server}
+	if adminEngine != nil {
+		// admin routers can't be bound to host, so host rewriting is not applied
+		servers = append(servers, newPlainServer(adminAddr, adminEngine))
+	}
+	serveUntilShutdown(servers...)
+}
+
+// Build engine of public listener with registered routers, static mounts and endpoints.
+func newEngine() *gin.Engine {
 	engine := gin.Default()
+	engine.Use(corsMiddleware())
 
-	AddRouter(engine, &rootRouter)
+	if len(rootRouter.Children) != 0 {
+		AddRouter(engine, &rootRouter)
+	}
 	InitStatic(engine)
+	InitOpenAPI(engine)
+	InitRoutesEndpoint(engine)
+	initNoRoute(engine)
+	return engine
+}
 
-	engine.Run(Config.GetGinAddr())
+// Handler of public listener, request path is rewritten before routing of gin.
+func newPublicHandler(engine *gin.Engine) http.Handler {
+	return newVersionHandler(engine, newHostHandler(engine))
 }
 
 // It is shorthand for gs.RunApp(&gs.Configuration{})
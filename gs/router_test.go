@@ -0,0 +1,40 @@
+package gs
+
+import (
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+// middleware which appends its name to X-Trace header
+func traceMiddleware(name string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		c.Writer.Header().Add("X-Trace", name)
+	}
+}
+
+func TestLeafMiddleWares(t *testing.T) {
+	setupTest(t, nil)
+	AddGlobalMiddleware(traceMiddleware("global"))
+	rootRouter.Children = []Router{{Path: "/user", MiddleWares: []gin.HandlerFunc{traceMiddleware("group")}, Children: []Router{
+		{Path: "/list", Method: GET, Handlers: []gin.HandlerFunc{stringHandler("list")}},
+		{Path: "/:id", Method: GET, MiddleWares: []gin.HandlerFunc{traceMiddleware("leaf")}, Handlers: []gin.HandlerFunc{stringHandler("user")}},
+		{Path: "/public", Method: GET, SkipParentMiddleWares: true, MiddleWares: []gin.HandlerFunc{traceMiddleware("public")},
+			Handlers: []gin.HandlerFunc{stringHandler("public")}},
+	}}}
+	handler := newTestHandler(t)
+
+	tests := map[string]string{
+		"/user/list":   "global,group",
+		"/user/1":      "global,group,leaf",
+		"/user/public": "public",
+	}
+	for path, want := range tests {
+		w := doRequest(handler, testRequest{target: path})
+		if got := strings.Join(w.Header().Values("X-Trace"), ","); w.Code != http.StatusOK || got != want {
+			t.Errorf("%s: got %d, middlewares %q, want %q", path, w.Code, got, want)
+		}
+	}
+}
@@ -0,0 +1,168 @@
+package gs
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+type RouteInfo struct {
+	Name string `json:"name,omitempty"`
+	// full path, including global preffix and paths of router groups
+	Path    string     `json:"path"`
+	Method  HttpMethod `json:"method"`
+	Methods []string   `json:"methods"`
+	// names of handlers, `Router.Function` is the last one
+	Handlers []string `json:"handlers"`
+	// names of middlewares applied before handlers, including those inherited from router groups
+	MiddleWares []string `json:"middleWares"`
+	// type of the controller which provides this route
+	Controller string `json:"controller"`
+	Deprecated bool   `json:"deprecated,omitempty"`
+	// whether it is served by admin listener
+	Admin  bool       `json:"admin,omitempty"`
+	Sunset *time.Time `json:"sunset,omitempty"`
+	// feature keys of itself and its parents, see `Router.Feature`
+	Features []string `json:"features,omitempty"`
+	// host pattern, see `Router.Host`
+	Host string `json:"host,omitempty"`
+}
+
+var routes = make([]RouteInfo, 0)
+
+// route name => index of routes
+var namedRoutes = make(map[string]int)
+
+func recordRoute(router ginEngineOrGroup, gsRouter *Router, scope routerScope) {
+	if gsRouter.Name != "" {
+		namedRoutes[gsRouter.Name] = len(routes)
+	}
+	var sunset *time.Time
+	if !scope.deprecation.sunset.IsZero() {
+		sunset = &scope.deprecation.sunset
+	}
+	fullPath := joinPaths(router.BasePath(), gsRouter.Path)
+	var host string
+	if scope.host != nil {
+		host = scope.host.pattern
+	}
+	handlers := getHandlerNames(gsRouter.Handlers)
+	if gsRouter.Function != nil {
+		handlers = append(handlers, gsRouter.getFunctionName())
+	}
+	routes = append(routes, RouteInfo{
+		Name:        gsRouter.Name,
+		Path:        fullPath,
+		Method:      gsRouter.Method,
+		Methods:     gsRouter.Method.names(),
+		Handlers:    handlers,
+		MiddleWares: getHandlerNames(scope.middleWares),
+		Controller:  scope.controller,
+		Deprecated:  scope.deprecation.enabled(),
+		Admin:       scope.admin,
+		Sunset:      sunset,
+		Features:    scope.features,
+		Host:        host,
+	})
+}
+
+func getHandlerNames(handlers []gin.HandlerFunc) []string {
+	names := make([]string, 0, len(handlers))
+	for _, handler := range handlers {
+		names = append(names, getFunctionName(handler))
+	}
+	return names
+}
+
+// Get routes registered by `AddRouter`, so it is empty before `RunApp`.
+func Routes() []RouteInfo {
+	result := make([]RouteInfo, len(routes))
+	copy(result, routes)
+	return result
+}
+
+// Build the full path of the named route, path parameters (:name and *name) are filled by
+// `params` in order. For example:
+//
+//	// Router{Path: "/user/:id/file/*path", Name: "userFile"}, global preffix is /api
+//	gs.URLFor("userFile", 1, "a/b.txt") // => /api/user/1/file/a/b.txt
+func URLFor(name string, params ...any) (string, error) {
+	index, ok := namedRoutes[name]
+	if !ok {
+		return "", fmt.Errorf("route %s not found", name)
+	}
+
+	segments := strings.Split(routes[index].Path, "/")
+	paramIndex := 0
+	for i, segment := range segments {
+		if !strings.HasPrefix(segment, ":") && !strings.HasPrefix(segment, "*") {
+			continue
+		}
+		if paramIndex >= len(params) {
+			return "", fmt.Errorf("not enough params for route %s, got %d", name, len(params))
+		}
+		value := fmt.Sprint(params[paramIndex])
+		paramIndex++
+		if segment[0] == ':' {
+			// gin routes by unescaped path, so escaped '/' can't be matched by :name
+			if strings.Contains(value, "/") {
+				return "", fmt.Errorf("param %s of route %s can't contain '/', use *%s instead", segment[1:], name, segment[1:])
+			}
+			segments[i] = url.PathEscape(value)
+		} else {
+			// catch-all parameter can contain '/'
+			parts := strings.Split(strings.TrimPrefix(value, "/"), "/")
+			for j, part := range parts {
+				parts[j] = url.PathEscape(part)
+			}
+			segments[i] = strings.Join(parts, "/")
+		}
+	}
+	if paramIndex != len(params) {
+		return "", fmt.Errorf("route %s requires %d params, but got %d", name, paramIndex, len(params))
+	}
+	return strings.Join(segments, "/"), nil
+}
+
+// Print route table to stdout.
+func PrintRoutes() {
+	writer := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(writer, "METHODS\tPATH\tNAME\tHANDLERS\tMIDDLEWARES\tCONTROLLER")
+	for _, route := range routes {
+		path := route.Path
+		if route.Host != "" {
+			path = route.Host + path
+		}
+		if route.Admin {
+			path = "(admin) " + path
+		}
+		fmt.Fprintf(writer, "%s\t%s\t%s\t%s\t%s\t%s\n",
+			strings.Join(route.Methods, ","),
+			path,
+			route.Name,
+			strings.Join(route.Handlers, ","),
+			strings.Join(route.MiddleWares, ","),
+			route.Controller,
+		)
+	}
+	writer.Flush()
+}
+
+// Register route listing endpoint if `routes.path` is set.
+//
+// It won't be affected by `SetGlobalPreffix`.
+func InitRoutesEndpoint(engine *gin.Engine) {
+	urlPath := getRoutesConfig().Path
+	if urlPath == "" {
+		return
+	}
+	engine.GET(urlPath, func(c *gin.Context) {
+		c.JSON(http.StatusOK, Routes())
+	})
+}
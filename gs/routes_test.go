@@ -0,0 +1,68 @@
+package gs
+
+import (
+	"encoding/json"
+	"net/http"
+	"testing"
+
+	"github.com/dan-kuroto/gin-stronger/config"
+	"github.com/gin-gonic/gin"
+)
+
+func TestRoutesEndpoint(t *testing.T) {
+	setupTest(t, func(cfg *config.Configuration) {
+		cfg.Routes.Path = "/_routes"
+	})
+	SetGlobalPreffix("/api")
+	rootRouter.Children = []Router{{Path: "/user", Children: []Router{
+		{Path: "/:id", Method: GET | HEAD, Name: "user", Handlers: []gin.HandlerFunc{stringHandler("user")}},
+	}}}
+	handler := newTestHandler(t)
+
+	w := doRequest(handler, testRequest{target: "/_routes"})
+	var result []RouteInfo
+	if err := json.Unmarshal(w.Body.Bytes(), &result); err != nil {
+		t.Fatalf("got %d %q: %v", w.Code, w.Body.String(), err)
+	}
+	if len(result) != 1 || result[0].Path != "/api/user/:id" || result[0].Name != "user" ||
+		len(result[0].Methods) != 2 || result[0].Methods[0] != http.MethodGet || result[0].Methods[1] != http.MethodHead {
+		t.Errorf("got routes %+v", result)
+	}
+}
+
+func TestURLFor(t *testing.T) {
+	setupTest(t, nil)
+	SetGlobalPreffix("/api")
+	rootRouter.Children = []Router{
+		{Path: "/user/:id", Method: GET, Name: "user", Handlers: []gin.HandlerFunc{stringHandler("user")}},
+		{Path: "/user/:id/file/*path", Method: GET, Name: "userFile", Handlers: []gin.HandlerFunc{stringHandler("file")}},
+	}
+	handler := newTestHandler(t)
+
+	tests := []struct {
+		name   string
+		params []any
+		url    string
+		ok     bool
+	}{
+		{"user", []any{1}, "/api/user/1", true},
+		{"user", []any{"a b"}, "/api/user/a%20b", true},
+		{"user", []any{"a/b"}, "", false},
+		{"userFile", []any{1, "/a/b c.txt"}, "/api/user/1/file/a/b%20c.txt", true},
+		{"user", nil, "", false},
+		{"user", []any{1, 2}, "", false},
+		{"unknown", nil, "", false},
+	}
+	for _, test := range tests {
+		url, err := URLFor(test.name, test.params...)
+		if url != test.url || (err == nil) != test.ok {
+			t.Errorf("%s %v: got %q, %v", test.name, test.params, url, err)
+		}
+		// generated url is routed to the route
+		if err == nil {
+			if w := doRequest(handler, testRequest{target: url}); w.Code != http.StatusOK {
+				t.Errorf("%s %v: %s got %d", test.name, test.params, url, w.Code)
+			}
+		}
+	}
+}
@@ -1,6 +1,8 @@
 package gs
 
 import (
+	"context"
+	"sync"
 	"time"
 
 	"github.com/gin-gonic/gin"
@@ -40,8 +42,20 @@ func (task Task) Handle() {
 }
 
 var taskList = make([]Task, 0, 8)
+var taskListMutex sync.Mutex
+
+var schedulerStop = make(chan struct{})
+var schedulerStopOnce sync.Once
+
+// closed when scheduler loop exits
+var schedulerDone = make(chan struct{})
+
+// goroutines of Task.Handle which are running
+var runningTasks sync.WaitGroup
 
 func AddTasks(tasks ...Task) {
+	taskListMutex.Lock()
+	defer taskListMutex.Unlock()
 	taskList = append(taskList, tasks...)
 }
 
@@ -112,15 +126,27 @@ func NewDailyTask(name string, hour, minute int, func_ func(*gin.Context)) *Task
 
 func init() {
 	go func() {
+		defer close(schedulerDone)
 		tick := time.NewTicker(1 * time.Second)
-		for range tick.C {
+		defer tick.Stop()
+		for {
+			select {
+			case <-schedulerStop:
+				return
+			case <-tick.C:
+			}
+			taskListMutex.Lock()
 			neoTaskList := make([]Task, 0, len(taskList)/2)
 			for _, task := range taskList {
 				if task.LastTime.IsZero() {
 					task.LastTime = time.Now()
 				} else if time.Since(task.LastTime) >= task.Period {
 					if task.Loop != 0 { // 小于零则无限循环,故条件不是大于零
-						go task.Handle()
+						runningTasks.Add(1)
+						go func(task Task) {
+							defer runningTasks.Done()
+							task.Handle()
+						}(task)
 						task.LastTime = time.Now()
 					}
 					if task.Loop > 0 { // 怕负数溢出什么的,故条件不是不等于零
@@ -132,8 +158,30 @@ func init() {
 				}
 			}
 			taskList = neoTaskList
+			taskListMutex.Unlock()
 		}
 	}()
 
 	GetLoggerByGinCtx(nil).Info().Msg("scheduler init complete")
 }
+
+// Stop scheduling new tasks, and wait for running tasks until ctx is done.
+func StopScheduler(ctx context.Context) error {
+	schedulerStopOnce.Do(func() {
+		close(schedulerStop)
+	})
+
+	done := make(chan struct{})
+	go func() {
+		// no task will be started after scheduler loop exits
+		<-schedulerDone
+		runningTasks.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
@@ -0,0 +1,330 @@
+package gs
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"net"
+	"net/http"
+	"os"
+	"os/user"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/dan-kuroto/gin-stronger/config"
+	"github.com/rs/zerolog/log"
+	"golang.org/x/net/http2"
+	"golang.org/x/net/http2/h2c"
+)
+
+var tlsVersions = map[string]uint16{
+	"1.0": tls.VersionTLS10,
+	"1.1": tls.VersionTLS11,
+	"1.2": tls.VersionTLS12,
+	"1.3": tls.VersionTLS13,
+}
+
+var tlsClientAuthTypes = map[string]tls.ClientAuthType{
+	"request":            tls.RequestClientCert,
+	"require":            tls.RequireAnyClientCert,
+	"verify-if-given":    tls.VerifyClientCertIfGiven,
+	"require-and-verify": tls.RequireAndVerifyClientCert,
+}
+
+// Build http.Server of public listener by `server` config.
+//
+// HTTP/2 is enabled automatically with TLS, and h2c (HTTP/2 without TLS) is optional.
+func newServer(addr string, handler http.Handler) (*http.Server, error) {
+	cfg := getServerConfig()
+	server := newPlainServer(addr, handler)
+	if cfg.TLS.CertFile != "" {
+		tlsConfig, err := newTLSConfig(cfg.TLS)
+		if err != nil {
+			return nil, err
+		}
+		server.TLSConfig = tlsConfig
+	} else if cfg.H2C {
+		server.Handler = h2c.NewHandler(handler, &http2.Server{})
+	}
+	return server, nil
+}
+
+// http.Server with limits of `server` config, but without TLS and h2c
+func newPlainServer(addr string, handler http.Handler) *http.Server {
+	cfg := getServerConfig()
+	return &http.Server{
+		Addr:              addr,
+		Handler:           handler,
+		MaxHeaderBytes:    int(cfg.MaxHeaderSize),
+		ReadTimeout:       cfg.ReadTimeout,
+		ReadHeaderTimeout: cfg.ReadHeaderTimeout,
+		WriteTimeout:      cfg.WriteTimeout,
+		IdleTimeout:       cfg.IdleTimeout,
+	}
+}
+
+const unixAddrPrefix = "unix:"
+
+var inheritedMutex sync.Mutex
+var inheritedListeners []net.Listener
+var inheritedLoaded bool
+
+// Listen on the address of server. Listeners inherited by socket activation (LISTEN_FDS)
+// are taken in order first: the first one for public listener and the second one for admin listener.
+func listen(server *http.Server) (net.Listener, error) {
+	if listener, err := takeInheritedListener(); listener != nil || err != nil {
+		return listener, err
+	}
+	if path, ok := strings.CutPrefix(server.Addr, unixAddrPrefix); ok {
+		return listenUnix(path, getServerConfig().UnixSocket)
+	}
+	return net.Listen("tcp", server.Addr)
+}
+
+func takeInheritedListener() (net.Listener, error) {
+	inheritedMutex.Lock()
+	defer inheritedMutex.Unlock()
+
+	if !inheritedLoaded {
+		inheritedLoaded = true
+		listeners, err := loadInheritedListeners()
+		if err != nil {
+			return nil, err
+		}
+		inheritedListeners = listeners
+	}
+	if len(inheritedListeners) == 0 {
+		return nil, nil
+	}
+	listener := inheritedListeners[0]
+	inheritedListeners = inheritedListeners[1:]
+	return listener, nil
+}
+
+// See sd_listen_fds(3). File descriptors are passed from 3, and LISTEN_PID must be the current process.
+func loadInheritedListeners() ([]net.Listener, error) {
+	pid, err := strconv.Atoi(os.Getenv("LISTEN_PID"))
+	if err != nil || pid != os.Getpid() {
+		return nil, nil
+	}
+	count, err := strconv.Atoi(os.Getenv("LISTEN_FDS"))
+	if err != nil || count <= 0 {
+		return nil, nil
+	}
+	names := strings.Split(os.Getenv("LISTEN_FDNAMES"), ":")
+	// child processes should not take them again
+	os.Unsetenv("LISTEN_PID")
+	os.Unsetenv("LISTEN_FDS")
+	os.Unsetenv("LISTEN_FDNAMES")
+
+	listeners := make([]net.Listener, 0, count)
+	for i := 0; i < count; i++ {
+		name := fmt.Sprintf("LISTEN_FD_%d", 3+i)
+		if i < len(names) && names[i] != "" {
+			name = names[i]
+		}
+		file := os.NewFile(uintptr(3+i), name)
+		// FileListener duplicates the descriptor, so the original one is closed
+		listener, err := net.FileListener(file)
+		file.Close()
+		if err != nil {
+			return nil, fmt.Errorf("inherited socket %s: %w", name, err)
+		}
+		log.Info().Str("name", name).Str("addr", listener.Addr().String()).Msg("inherited socket found")
+		listeners = append(listeners, listener)
+	}
+	return listeners, nil
+}
+
+// Listen on unix domain socket. Stale socket file left by crashed process is removed,
+// but it fails if the socket is still being listened by another process.
+func listenUnix(path string, cfg config.UnixSocketConfig) (net.Listener, error) {
+	if info, err := os.Lstat(path); err == nil {
+		if info.Mode()&os.ModeSocket == 0 {
+			return nil, fmt.Errorf("%s exists and is not a socket", path)
+		}
+		if conn, err := net.Dial("unix", path); err == nil {
+			conn.Close()
+			return nil, fmt.Errorf("%s is in use by another process", path)
+		}
+		if err := os.Remove(path); err != nil {
+			return nil, err
+		}
+		log.Warn().Str("path", path).Msg("stale socket removed")
+	}
+
+	// socket file is removed when listener is closed
+	listener, err := net.Listen("unix", path)
+	if err != nil {
+		return nil, err
+	}
+	if err := setSocketFileMode(path, cfg); err != nil {
+		listener.Close()
+		return nil, err
+	}
+	return listener, nil
+}
+
+func setSocketFileMode(path string, cfg config.UnixSocketConfig) error {
+	if cfg.Mode != "" {
+		mode, err := strconv.ParseUint(cfg.Mode, 8, 32)
+		if err != nil {
+			return fmt.Errorf("invalid unix socket mode: %s", cfg.Mode)
+		}
+		if err := os.Chmod(path, os.FileMode(mode)); err != nil {
+			return err
+		}
+	}
+
+	if cfg.Owner == "" && cfg.Group == "" {
+		return nil
+	}
+	uid, gid := -1, -1
+	if cfg.Owner != "" {
+		id := cfg.Owner
+		if u, err := user.Lookup(cfg.Owner); err == nil {
+			id = u.Uid
+		}
+		var err error
+		if uid, err = strconv.Atoi(id); err != nil {
+			return fmt.Errorf("unknown unix socket owner: %s", cfg.Owner)
+		}
+	}
+	if cfg.Group != "" {
+		id := cfg.Group
+		if g, err := user.LookupGroup(cfg.Group); err == nil {
+			id = g.Gid
+		}
+		var err error
+		if gid, err = strconv.Atoi(id); err != nil {
+			return fmt.Errorf("unknown unix socket group: %s", cfg.Group)
+		}
+	}
+	return os.Chown(path, uid, gid)
+}
+
+func serve(server *http.Server, listener net.Listener) error {
+	if server.TLSConfig != nil {
+		// certificate is given by TLSConfig.GetCertificate
+		return server.ServeTLS(listener, "", "")
+	}
+	return server.Serve(listener)
+}
+
+func newTLSConfig(cfg config.TLSConfig) (*tls.Config, error) {
+	reloader := &certReloader{certFile: cfg.CertFile, keyFile: cfg.KeyFile}
+	if err := reloader.load(); err != nil {
+		return nil, err
+	}
+
+	minVersion, ok := tlsVersions[cfg.MinVersion]
+	if !ok {
+		return nil, fmt.Errorf("invalid TLS min version: %s", cfg.MinVersion)
+	}
+	tlsConfig := &tls.Config{
+		MinVersion:     minVersion,
+		GetCertificate: reloader.GetCertificate,
+	}
+
+	if len(cfg.CipherSuites) != 0 {
+		suiteIds := make(map[string]uint16)
+		for _, suite := range append(tls.CipherSuites(), tls.InsecureCipherSuites()...) {
+			suiteIds[suite.Name] = suite.ID
+		}
+		for _, name := range cfg.CipherSuites {
+			id, ok := suiteIds[name]
+			if !ok {
+				return nil, fmt.Errorf("unknown TLS cipher suite: %s", name)
+			}
+			tlsConfig.CipherSuites = append(tlsConfig.CipherSuites, id)
+		}
+	}
+
+	if cfg.ClientCAFile != "" {
+		data, err := os.ReadFile(cfg.ClientCAFile)
+		if err != nil {
+			return nil, err
+		}
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(data) {
+			return nil, fmt.Errorf("no certificate found in %s", cfg.ClientCAFile)
+		}
+		clientAuth, ok := tlsClientAuthTypes[cfg.ClientAuth]
+		if !ok {
+			return nil, fmt.Errorf("invalid TLS client auth: %s", cfg.ClientAuth)
+		}
+		tlsConfig.ClientCAs = pool
+		tlsConfig.ClientAuth = clientAuth
+	}
+	return tlsConfig, nil
+}
+
+// Reload certificate when cert or key file changes. Files are checked at most once per second.
+type certReloader struct {
+	certFile string
+	keyFile  string
+
+	mutex     sync.Mutex
+	cert      *tls.Certificate
+	modTime   time.Time
+	checkedAt time.Time
+}
+
+func (reloader *certReloader) GetCertificate(*tls.ClientHelloInfo) (*tls.Certificate, error) {
+	reloader.mutex.Lock()
+	defer reloader.mutex.Unlock()
+
+	if time.Since(reloader.checkedAt) >= time.Second {
+		reloader.checkedAt = time.Now()
+		if modTime, err := reloader.getModTime(); err != nil {
+			log.Err(err).Msg("check TLS certificate failed")
+		} else if modTime.After(reloader.modTime) {
+			if err := reloader.loadLocked(); err != nil {
+				// keep using the old certificate, files may be being written
+				log.Err(err).Msg("reload TLS certificate failed")
+			} else {
+				log.Info().Str("cert", reloader.certFile).Msg("TLS certificate reloaded")
+			}
+		}
+	}
+	return reloader.cert, nil
+}
+
+func (reloader *certReloader) load() error {
+	reloader.mutex.Lock()
+	defer reloader.mutex.Unlock()
+	return reloader.loadLocked()
+}
+
+func (reloader *certReloader) loadLocked() error {
+	modTime, err := reloader.getModTime()
+	if err != nil {
+		return err
+	}
+	cert, err := tls.LoadX509KeyPair(reloader.certFile, reloader.keyFile)
+	if err != nil {
+		return err
+	}
+	reloader.cert = &cert
+	reloader.modTime = modTime
+	reloader.checkedAt = time.Now()
+	return nil
+}
+
+// the latest modification time of cert and key files
+func (reloader *certReloader) getModTime() (time.Time, error) {
+	certInfo, err := os.Stat(reloader.certFile)
+	if err != nil {
+		return time.Time{}, err
+	}
+	keyInfo, err := os.Stat(reloader.keyFile)
+	if err != nil {
+		return time.Time{}, err
+	}
+	if keyInfo.ModTime().After(certInfo.ModTime()) {
+		return keyInfo.ModTime(), nil
+	}
+	return certInfo.ModTime(), nil
+}
@@ -0,0 +1,249 @@
+package gs
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"net"
+	"os"
+	"path/filepath"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/dan-kuroto/gin-stronger/config"
+)
+
+// Write a self-signed certificate of `name` and its key into dir, and return paths of them.
+func writeTestCert(t *testing.T, dir string, name string) (certFile string, keyFile string) {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	template := &x509.Certificate{
+		SerialNumber:          big.NewInt(time.Now().UnixNano()),
+		Subject:               pkix.Name{CommonName: name},
+		DNSNames:              []string{name},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		IsCA:                  true,
+		BasicConstraintsValid: true,
+		KeyUsage:              x509.KeyUsageDigitalSignature | x509.KeyUsageCertSign,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	keyDer, err := x509.MarshalECPrivateKey(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	certFile, keyFile = filepath.Join(dir, "cert.pem"), filepath.Join(dir, "key.pem")
+	if err := os.WriteFile(certFile, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(keyFile, pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDer}), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	return certFile, keyFile
+}
+
+// common name of the certificate given by tlsConfig
+func getCertName(t *testing.T, tlsConfig *tls.Config) string {
+	t.Helper()
+	cert, err := tlsConfig.GetCertificate(&tls.ClientHelloInfo{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	leaf, err := x509.ParseCertificate(cert.Certificate[0])
+	if err != nil {
+		t.Fatal(err)
+	}
+	return leaf.Subject.CommonName
+}
+
+func TestNewTLSConfig(t *testing.T) {
+	dir := t.TempDir()
+	certFile, keyFile := writeTestCert(t, dir, "example.com")
+	badCAFile := filepath.Join(dir, "bad-ca.pem")
+	if err := os.WriteFile(badCAFile, []byte("not a certificate"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name   string
+		modify func(cfg *config.TLSConfig)
+		check  func(tlsConfig *tls.Config) bool
+		ok     bool
+	}{
+		{"min version", func(cfg *config.TLSConfig) { cfg.MinVersion = "1.3" }, func(tlsConfig *tls.Config) bool {
+			return tlsConfig.MinVersion == tls.VersionTLS13 && tlsConfig.ClientAuth == tls.NoClientCert
+		}, true},
+		{"invalid min version", func(cfg *config.TLSConfig) { cfg.MinVersion = "1.4" }, nil, false},
+		{"cipher suites", func(cfg *config.TLSConfig) {
+			cfg.CipherSuites = []string{"TLS_ECDHE_ECDSA_WITH_AES_128_GCM_SHA256", "TLS_RSA_WITH_AES_128_CBC_SHA"}
+		}, func(tlsConfig *tls.Config) bool {
+			return len(tlsConfig.CipherSuites) == 2 &&
+				tlsConfig.CipherSuites[0] == tls.TLS_ECDHE_ECDSA_WITH_AES_128_GCM_SHA256 &&
+				tlsConfig.CipherSuites[1] == tls.TLS_RSA_WITH_AES_128_CBC_SHA
+		}, true},
+		{"unknown cipher suite", func(cfg *config.TLSConfig) { cfg.CipherSuites = []string{"TLS_UNKNOWN"} }, nil, false},
+		{"client auth request", func(cfg *config.TLSConfig) {
+			cfg.ClientCAFile, cfg.ClientAuth = certFile, "request"
+		}, func(tlsConfig *tls.Config) bool {
+			return tlsConfig.ClientAuth == tls.RequestClientCert && tlsConfig.ClientCAs != nil
+		}, true},
+		{"client auth verify-if-given", func(cfg *config.TLSConfig) {
+			cfg.ClientCAFile, cfg.ClientAuth = certFile, "verify-if-given"
+		}, func(tlsConfig *tls.Config) bool { return tlsConfig.ClientAuth == tls.VerifyClientCertIfGiven }, true},
+		{"client auth require-and-verify", func(cfg *config.TLSConfig) {
+			cfg.ClientCAFile, cfg.ClientAuth = certFile, "require-and-verify"
+		}, func(tlsConfig *tls.Config) bool { return tlsConfig.ClientAuth == tls.RequireAndVerifyClientCert }, true},
+		{"invalid client auth", func(cfg *config.TLSConfig) {
+			cfg.ClientCAFile, cfg.ClientAuth = certFile, "always"
+		}, nil, false},
+		{"bad CA bundle", func(cfg *config.TLSConfig) {
+			cfg.ClientCAFile, cfg.ClientAuth = badCAFile, "require-and-verify"
+		}, nil, false},
+		{"missing CA bundle", func(cfg *config.TLSConfig) {
+			cfg.ClientCAFile, cfg.ClientAuth = filepath.Join(dir, "missing.pem"), "require-and-verify"
+		}, nil, false},
+		{"missing key", func(cfg *config.TLSConfig) { cfg.KeyFile = filepath.Join(dir, "missing.pem") }, nil, false},
+	}
+	for _, test := range tests {
+		cfg := config.TLSConfig{CertFile: certFile, KeyFile: keyFile, MinVersion: "1.2"}
+		test.modify(&cfg)
+		tlsConfig, err := newTLSConfig(cfg)
+		if !test.ok {
+			if err == nil {
+				t.Errorf("%s: got no error", test.name)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("%s: %v", test.name, err)
+		} else if !test.check(tlsConfig) {
+			t.Errorf("%s: got unexpected config %+v", test.name, tlsConfig)
+		} else if name := getCertName(t, tlsConfig); name != "example.com" {
+			t.Errorf("%s: got certificate of %s", test.name, name)
+		}
+	}
+}
+
+func TestCertReloader(t *testing.T) {
+	dir := t.TempDir()
+	certFile, keyFile := writeTestCert(t, dir, "old.example.com")
+	reloader := &certReloader{certFile: certFile, keyFile: keyFile}
+	if err := reloader.load(); err != nil {
+		t.Fatal(err)
+	}
+	tlsConfig := &tls.Config{GetCertificate: reloader.GetCertificate}
+	// mtime may not change if files are rewritten within the same tick, so set it explicitly
+	touch := func(modTime time.Time) {
+		t.Helper()
+		for _, file := range []string{certFile, keyFile} {
+			if err := os.Chtimes(file, modTime, modTime); err != nil {
+				t.Fatal(err)
+			}
+		}
+	}
+
+	// broken files keep the old certificate
+	if err := os.WriteFile(keyFile, []byte("being written"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	touch(time.Now().Add(time.Minute))
+	reloader.checkedAt = time.Time{}
+	if name := getCertName(t, tlsConfig); name != "old.example.com" {
+		t.Errorf("got certificate of %s after files are broken, want old.example.com", name)
+	}
+
+	// files are checked at most once per second
+	writeTestCert(t, dir, "new.example.com")
+	modTime := time.Now().Add(2 * time.Minute)
+	touch(modTime)
+	if name := getCertName(t, tlsConfig); name != "old.example.com" {
+		t.Errorf("got certificate of %s within a second, want old.example.com", name)
+	}
+
+	reloader.checkedAt = time.Time{}
+	if name := getCertName(t, tlsConfig); name != "new.example.com" {
+		t.Errorf("got certificate of %s after mtime changes, want new.example.com", name)
+	}
+
+	// unchanged mtime doesn't reload
+	writeTestCert(t, dir, "newer.example.com")
+	touch(modTime)
+	reloader.checkedAt = time.Time{}
+	if name := getCertName(t, tlsConfig); name != "new.example.com" {
+		t.Errorf("got certificate of %s when mtime is unchanged, want new.example.com", name)
+	}
+}
+
+func TestListenUnix(t *testing.T) {
+	dir := t.TempDir()
+
+	// stale socket left by crashed process
+	stalePath := filepath.Join(dir, "stale.sock")
+	stale, err := net.Listen("unix", stalePath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	stale.(*net.UnixListener).SetUnlinkOnClose(false)
+	stale.Close()
+	listener, err := listenUnix(stalePath, config.UnixSocketConfig{Mode: "0600"})
+	if err != nil {
+		t.Fatalf("stale socket: %v", err)
+	}
+	defer listener.Close()
+	if info, err := os.Stat(stalePath); err != nil {
+		t.Error(err)
+	} else if info.Mode().Perm() != 0o600 {
+		t.Errorf("got mode %o, want 600", info.Mode().Perm())
+	}
+
+	// socket still being listened
+	if _, err := listenUnix(stalePath, config.UnixSocketConfig{}); err == nil {
+		t.Error("live socket: got no error")
+	}
+	if _, err := os.Stat(stalePath); err != nil {
+		t.Errorf("live socket is removed: %v", err)
+	}
+
+	// not a socket
+	filePath := filepath.Join(dir, "file.sock")
+	if err := os.WriteFile(filePath, []byte("data"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := listenUnix(filePath, config.UnixSocketConfig{}); err == nil {
+		t.Error("regular file: got no error")
+	}
+	if data, err := os.ReadFile(filePath); err != nil || string(data) != "data" {
+		t.Errorf("regular file is changed: %q %v", data, err)
+	}
+
+	if _, err := listenUnix(filepath.Join(dir, "invalid-mode.sock"), config.UnixSocketConfig{Mode: "rw"}); err == nil {
+		t.Error("invalid mode: got no error")
+	}
+}
+
+func TestLoadInheritedListenersOtherPid(t *testing.T) {
+	t.Setenv("LISTEN_PID", strconv.Itoa(os.Getpid()+1))
+	t.Setenv("LISTEN_FDS", "1")
+
+	listeners, err := loadInheritedListeners()
+	if err != nil || len(listeners) != 0 {
+		t.Errorf("got %v %v, want no listener", listeners, err)
+	}
+	// they belong to another process, so they are kept as is
+	if os.Getenv("LISTEN_FDS") != "1" {
+		t.Error("LISTEN_FDS is unset")
+	}
+}
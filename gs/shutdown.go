@@ -0,0 +1,97 @@
+package gs
+
+import (
+	"context"
+	"errors"
+	"net"
+	"net/http"
+	"os/signal"
+	"sync"
+	"syscall"
+
+	"github.com/rs/zerolog/log"
+)
+
+// hook() will be called when app is shutting down, after servers and scheduler are stopped.
+//
+// It is shorthand for `AddHook` with PhaseShutdown.
+func OnShutdown(hook func()) {
+	AddHook(Hook{
+		Name:  "OnShutdown",
+		Phase: PhaseShutdown,
+		Func: func(context.Context) error {
+			hook()
+			return nil
+		},
+	})
+}
+
+// Serve all servers until SIGINT/SIGTERM is received or any server fails, then shutdown gracefully:
+// stop accepting connections, drain in-flight requests, stop scheduler and wait for running tasks,
+// and call shutdown hooks at last.
+func serveUntilShutdown(servers ...*http.Server) {
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer stop()
+
+	// listen synchronously, so that PhaseServerStarted means connections can be accepted
+	listeners := make([]net.Listener, 0, len(servers))
+	for _, server := range servers {
+		listener, err := listen(server)
+		if err != nil {
+			log.Fatal().Err(err).Str("addr", server.Addr).Msg("listen failed")
+		}
+		log.Info().Str("addr", listener.Addr().String()).Msg("server started")
+		listeners = append(listeners, listener)
+	}
+
+	serverErr := make(chan error, len(servers))
+	for i, server := range servers {
+		go func(server *http.Server, listener net.Listener) {
+			if err := serve(server, listener); err != nil && !errors.Is(err, http.ErrServerClosed) {
+				log.Err(err).Str("addr", server.Addr).Msg("server stopped")
+				serverErr <- err
+			}
+		}(server, listeners[i])
+	}
+
+	if err := runHooks(PhaseServerStarted); err != nil {
+		log.Err(err).Msg("startup aborted")
+	} else {
+		select {
+		case <-ctx.Done():
+			log.Info().Msg("shutdown signal received")
+		case <-serverErr:
+		}
+	}
+	stop()
+
+	Shutdown(servers...)
+}
+
+// Shutdown servers and scheduler gracefully within `server.shutdown-timeout`, then call shutdown hooks.
+func Shutdown(servers ...*http.Server) {
+	ctx, cancel := context.WithTimeout(context.Background(), getServerConfig().ShutdownTimeout)
+	defer cancel()
+
+	var wg sync.WaitGroup
+	for _, server := range servers {
+		wg.Add(1)
+		go func(server *http.Server) {
+			defer wg.Done()
+			if err := server.Shutdown(ctx); err != nil {
+				log.Err(err).Str("addr", server.Addr).Msg("shutdown server failed")
+			}
+		}(server)
+	}
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		if err := StopScheduler(ctx); err != nil {
+			log.Err(err).Msg("wait for running tasks failed")
+		}
+	}()
+	wg.Wait()
+
+	runHooks(PhaseShutdown)
+	log.Info().Msg("shutdown complete")
+}
@@ -0,0 +1,68 @@
+package gs
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/dan-kuroto/gin-stronger/config"
+	"github.com/gin-gonic/gin"
+)
+
+// Scheduler can only be stopped once, so running tasks and timeout are tested together.
+func TestShutdown(t *testing.T) {
+	setupTest(t, func(cfg *config.Configuration) {
+		cfg.Server.ShutdownTimeout = 5 * time.Second
+	})
+	started, release := make(chan struct{}), make(chan struct{})
+	var finished atomic.Bool
+	AddTasks(*NewRepeatTaskImmediately("slow", 0, 1, func(c *gin.Context) {
+		close(started)
+		<-release
+		finished.Store(true)
+	}))
+	var finishedBeforeHook atomic.Bool
+	OnShutdown(func() {
+		finishedBeforeHook.Store(finished.Load())
+	})
+	select {
+	case <-started:
+	case <-time.After(3 * time.Second):
+		t.Fatal("task is not started by scheduler")
+	}
+
+	server := httptest.NewServer(http.NotFoundHandler())
+	done := make(chan struct{})
+	go func() {
+		Shutdown(server.Config)
+		close(done)
+	}()
+	select {
+	case <-done:
+		t.Fatal("Shutdown returned before running task finished")
+	case <-time.After(50 * time.Millisecond):
+	}
+	close(release)
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("Shutdown didn't return after running task finished")
+	}
+	if !finishedBeforeHook.Load() {
+		t.Error("shutdown hook is called before running task finished")
+	}
+
+	// a task which never finishes
+	setupTest(t, func(cfg *config.Configuration) {
+		cfg.Server.ShutdownTimeout = 50 * time.Millisecond
+	})
+	runningTasks.Add(1)
+	defer runningTasks.Done()
+	begin := time.Now()
+	Shutdown()
+	if elapsed := time.Since(begin); elapsed < 50*time.Millisecond || elapsed > time.Second {
+		t.Errorf("Shutdown returned after %s, want shutdown timeout 50ms", elapsed)
+	}
+}
@@ -0,0 +1,273 @@
+package gs
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"html"
+	"io"
+	"io/fs"
+	"mime"
+	"net/http"
+	"net/url"
+	"os"
+	"path"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/gin-gonic/gin"
+)
+
+type StaticMount struct {
+	// url path, e.g. /static. mount at / is served only when no route matches
+	Path string
+	// directory or file on disk, it is ignored if FS is set
+	Dir string
+	// e.g. embed.FS, use fs.Sub to serve its sub directory
+	FS fs.FS
+	// serve {Index} of root for unknown paths, used by SPA with history mode
+	SPAFallback bool
+	// index file of directories. default value is index.html
+	Index string
+	// value of Cache-Control header, e.g. "public, max-age=86400". it is not set if empty
+	CacheControl string
+	// add strong ETag computed from file content, and handle If-None-Match
+	ETag bool
+	// serve {file}.gz instead of {file} if it exists and client accepts gzip
+	Precompressed bool
+	// list files of directory which has no index file
+	ListDirectory bool
+	// mount under the preffix set by `SetGlobalPreffix`
+	UseGlobalPreffix bool
+}
+
+var staticMounts = make([]StaticMount, 0)
+
+// Register static files. `url2path` is the mapping of url to file path
+// (directory path is supported).
+//
+// It won't be affected by `SetGlobalPreffix`.
+// Mounts are registered in url order, use `AddStatic` for more options.
+func SetStatic(getter StaticMapFunc) {
+	staticMapFunc = getter
+}
+
+// Register static mounts, they are registered in the order of calls.
+func AddStatic(mounts ...StaticMount) {
+	staticMounts = append(staticMounts, mounts...)
+}
+
+func InitStatic(engine *gin.Engine) {
+	mounts := make([]StaticMount, 0, len(staticMounts))
+	if staticMapFunc != nil {
+		url2path := staticMapFunc()
+		urlPaths := make([]string, 0, len(url2path))
+		for urlPath := range url2path {
+			urlPaths = append(urlPaths, urlPath)
+		}
+		sort.Strings(urlPaths)
+		for _, urlPath := range urlPaths {
+			mounts = append(mounts, StaticMount{Path: urlPath, Dir: url2path[urlPath]})
+		}
+	}
+	mounts = append(mounts, staticMounts...)
+
+	for _, mount := range mounts {
+		mountStatic(engine, mount)
+	}
+}
+
+func mountStatic(engine *gin.Engine, mount StaticMount) {
+	if mount.Index == "" {
+		mount.Index = "index.html"
+	}
+	urlPath := mount.Path
+	if mount.UseGlobalPreffix {
+		urlPath = joinPaths(rootRouter.Path, urlPath)
+	}
+	urlPath = "/" + strings.Trim(urlPath, "/")
+
+	// single file on disk
+	if mount.FS == nil {
+		if info, err := os.Stat(mount.Dir); err == nil && !info.IsDir() {
+			server := &staticServer{mount: mount, fsys: os.DirFS(path.Dir(mount.Dir))}
+			name := path.Base(mount.Dir)
+			handler := func(c *gin.Context) {
+				if !server.serveFile(c, name) {
+					c.AbortWithStatus(http.StatusNotFound)
+				}
+			}
+			engine.GET(urlPath, handler)
+			engine.HEAD(urlPath, handler)
+			return
+		}
+	}
+
+	server := &staticServer{mount: mount, fsys: mount.FS}
+	if server.fsys == nil {
+		server.fsys = os.DirFS(mount.Dir)
+	}
+
+	// catch-all at root conflicts with other routes, so it is served when no route matches
+	if urlPath == "/" {
+		addNoRouteHandler(func(c *gin.Context) {
+			if c.Request.Method != http.MethodGet && c.Request.Method != http.MethodHead {
+				return
+			}
+			if server.serve(c, c.Request.URL.Path) {
+				c.Abort()
+			}
+		})
+		return
+	}
+
+	handler := func(c *gin.Context) {
+		if !server.serve(c, c.Param("filepath")) {
+			c.AbortWithStatus(http.StatusNotFound)
+		}
+	}
+	engine.GET(urlPath+"/*filepath", handler)
+	engine.HEAD(urlPath+"/*filepath", handler)
+}
+
+type staticServer struct {
+	mount StaticMount
+	fsys  fs.FS
+	// "{name}|{size}|{modTime}" => ETag
+	etags sync.Map
+}
+
+// Return false if nothing is served.
+func (server *staticServer) serve(c *gin.Context, urlPath string) bool {
+	name := strings.TrimPrefix(path.Clean("/"+urlPath), "/")
+	if name == "" {
+		name = "."
+	}
+
+	info, err := fs.Stat(server.fsys, name)
+	if err == nil && info.IsDir() {
+		index := path.Join(name, server.mount.Index)
+		if indexInfo, err := fs.Stat(server.fsys, index); err == nil && !indexInfo.IsDir() {
+			return server.serveFile(c, index)
+		}
+		if server.mount.ListDirectory {
+			return server.listDirectory(c, name)
+		}
+	} else if err == nil {
+		return server.serveFile(c, name)
+	}
+
+	if server.mount.SPAFallback && path.Ext(name) == "" {
+		return server.serveFile(c, server.mount.Index)
+	}
+	return false
+}
+
+func (server *staticServer) serveFile(c *gin.Context, name string) bool {
+	header := c.Writer.Header()
+	servedName := name
+	if server.mount.Precompressed {
+		header.Add("Vary", "Accept-Encoding")
+		if acceptsEncoding(c.GetHeader("Accept-Encoding"), "gzip") {
+			if info, err := fs.Stat(server.fsys, name+".gz"); err == nil && !info.IsDir() {
+				servedName = name + ".gz"
+			}
+		}
+	}
+
+	file, err := server.fsys.Open(servedName)
+	if err != nil {
+		return false
+	}
+	defer file.Close()
+	info, err := file.Stat()
+	if err != nil || info.IsDir() {
+		return false
+	}
+
+	content, ok := file.(io.ReadSeeker)
+	if !ok {
+		data, err := io.ReadAll(file)
+		if err != nil {
+			return false
+		}
+		content = bytes.NewReader(data)
+	}
+
+	if servedName != name {
+		header.Set("Content-Encoding", "gzip")
+		if contentType := mime.TypeByExtension(path.Ext(name)); contentType != "" {
+			header.Set("Content-Type", contentType)
+		}
+	}
+	if server.mount.CacheControl != "" {
+		header.Set("Cache-Control", server.mount.CacheControl)
+	}
+	if server.mount.ETag {
+		etag, err := server.getETag(servedName, info, content)
+		if err != nil {
+			return false
+		}
+		header.Set("ETag", etag)
+	}
+	// Range, If-None-Match and If-Modified-Since are handled by ServeContent
+	http.ServeContent(c.Writer, c.Request, name, info.ModTime(), content)
+	return true
+}
+
+func (server *staticServer) getETag(name string, info fs.FileInfo, content io.ReadSeeker) (string, error) {
+	key := fmt.Sprintf("%s|%d|%d", name, info.Size(), info.ModTime().UnixNano())
+	if etag, ok := server.etags.Load(key); ok {
+		return etag.(string), nil
+	}
+
+	hash := sha256.New()
+	if _, err := io.Copy(hash, content); err != nil {
+		return "", err
+	}
+	if _, err := content.Seek(0, io.SeekStart); err != nil {
+		return "", err
+	}
+	etag := `"` + hex.EncodeToString(hash.Sum(nil)[:16]) + `"`
+	server.etags.Store(key, etag)
+	return etag, nil
+}
+
+// e.g. a:b?.txt => ./a:b%3F.txt, "./" prevents the name from being parsed as scheme
+func relativeHref(name string) string {
+	return "./" + url.PathEscape(name)
+}
+
+func (server *staticServer) listDirectory(c *gin.Context, name string) bool {
+	entries, err := fs.ReadDir(server.fsys, name)
+	if err != nil {
+		return false
+	}
+	// relative links only work with trailing slash. Redirect relatively like http.FileServer,
+	// since absolute path like //evil.com is a protocol-relative URL
+	if !strings.HasSuffix(c.Request.URL.Path, "/") {
+		location := relativeHref(path.Base(c.Request.URL.Path)) + "/"
+		if c.Request.URL.RawQuery != "" {
+			location += "?" + c.Request.URL.RawQuery
+		}
+		c.Header("Location", location)
+		c.Status(http.StatusMovedPermanently)
+		return true
+	}
+
+	var builder strings.Builder
+	builder.WriteString("<!DOCTYPE html>\n<pre>\n")
+	for _, entry := range entries {
+		entryName, href := entry.Name(), relativeHref(entry.Name())
+		if entry.IsDir() {
+			entryName += "/"
+			href += "/"
+		}
+		fmt.Fprintf(&builder, "<a href=\"%s\">%s</a>\n", html.EscapeString(href), html.EscapeString(entryName))
+	}
+	builder.WriteString("</pre>\n")
+	c.Data(http.StatusOK, "text/html; charset=utf-8", []byte(builder.String()))
+	return true
+}
@@ -0,0 +1,62 @@
+package gs
+
+import (
+	"net/http"
+	"strings"
+	"testing"
+	"testing/fstest"
+)
+
+func TestStatic(t *testing.T) {
+	setupTest(t, nil)
+	fsys := fstest.MapFS{
+		"index.html":          {Data: []byte("index")},
+		"app.js":              {Data: []byte("plain js")},
+		"app.js.gz":           {Data: []byte("gzipped js")},
+		"docs/a b?.txt":       {Data: []byte("a")},
+		"docs/sub/readme.txt": {Data: []byte("readme")},
+	}
+	AddStatic(
+		StaticMount{Path: "/static", FS: fsys, ETag: true, Precompressed: true, ListDirectory: true},
+		StaticMount{Path: "/", FS: fsys, SPAFallback: true},
+	)
+	handler := newTestHandler(t)
+
+	tests := []struct {
+		name     string
+		request  testRequest
+		status   int
+		body     string
+		location string
+	}{
+		{"file", testRequest{target: "/static/app.js"}, http.StatusOK, "plain js", ""},
+		{"precompressed", testRequest{target: "/static/app.js", header: map[string]string{"Accept-Encoding": "gzip, br"}},
+			http.StatusOK, "gzipped js", ""},
+		{"gzip not acceptable", testRequest{target: "/static/app.js", header: map[string]string{"Accept-Encoding": "gzip;q=0, br"}},
+			http.StatusOK, "plain js", ""},
+		{"directory redirect", testRequest{target: "/static/docs?x=1"}, http.StatusMovedPermanently, "", "./docs/?x=1"},
+		{"protocol-relative path", testRequest{target: "/static//docs"}, http.StatusMovedPermanently, "", "./docs/"},
+		{"listing", testRequest{target: "/static/docs/"}, http.StatusOK,
+			"<!DOCTYPE html>\n<pre>\n<a href=\"./a%20b%3F.txt\">a b?.txt</a>\n<a href=\"./sub/\">sub/</a>\n</pre>\n", ""},
+		{"missing", testRequest{target: "/static/missing.js"}, http.StatusNotFound, "", ""},
+		{"root", testRequest{target: "/app.js"}, http.StatusOK, "plain js", ""},
+		{"spa fallback", testRequest{target: "/users/1"}, http.StatusOK, "index", ""},
+	}
+	for _, test := range tests {
+		w := doRequest(handler, test.request)
+		if w.Code != test.status || (test.body != "" && w.Body.String() != test.body) ||
+			w.Header().Get("Location") != test.location {
+			t.Errorf("%s: got %d %q Location %q, want %d %q %q", test.name, w.Code, w.Body.String(),
+				w.Header().Get("Location"), test.status, test.body, test.location)
+		}
+	}
+
+	w := doRequest(handler, testRequest{target: "/static/app.js"})
+	etag := w.Header().Get("ETag")
+	if !strings.HasPrefix(etag, `"`) {
+		t.Fatalf("got ETag %q", etag)
+	}
+	if w := doRequest(handler, testRequest{target: "/static/app.js", header: map[string]string{"If-None-Match": etag}}); w.Code != http.StatusNotModified {
+		t.Errorf("If-None-Match: got %d, want 304", w.Code)
+	}
+}
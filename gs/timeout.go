@@ -0,0 +1,45 @@
+package gs
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// timeout of leaf router, 0 means no timeout
+func (scope *routerScope) getTimeout() time.Duration {
+	if scope.timeout != 0 || Config == nil {
+		return scope.timeout
+	}
+	return getServerConfig().RequestTimeout
+}
+
+// Set deadline of request context, and respond `server.timeout-status` if it elapses before
+// the response is written.
+//
+// Handlers run in the request goroutine (gin.Context is not goroutine safe), so they are not
+// interrupted, and should return early when c.Request.Context() is done. Result of packaged
+// handler is discarded after the deadline.
+func timeoutMiddleware(timeout time.Duration) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		ctx, cancel := context.WithTimeout(c.Request.Context(), timeout)
+		defer cancel()
+		c.Request = c.Request.WithContext(ctx)
+
+		c.Next()
+		if errors.Is(ctx.Err(), context.DeadlineExceeded) && !c.Writer.Written() {
+			abortWithTimeout(c)
+		}
+	}
+}
+
+func abortWithTimeout(c *gin.Context) {
+	status := http.StatusServiceUnavailable
+	if Config != nil {
+		status = getServerConfig().TimeoutStatus
+	}
+	AbortWithError(c, status, "request timeout")
+}
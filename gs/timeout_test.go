@@ -0,0 +1,52 @@
+package gs
+
+import (
+	"context"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestTimeout(t *testing.T) {
+	setupTest(t, nil)
+	rootRouter.Children = []Router{{Path: "", Timeout: 10 * time.Millisecond, Children: []Router{
+		{Path: "/fast", Handlers: PackageHandlers(func(ctx context.Context) string { return "fast" })},
+		{Path: "/slow", Handlers: PackageHandlers(func(ctx context.Context) string {
+			<-ctx.Done()
+			return "slow"
+		})},
+		{Path: "/partial", Handlers: PackageHandlers(func(c *gin.Context, ctx context.Context) string {
+			c.String(http.StatusOK, "partial")
+			<-ctx.Done()
+			return "slow"
+		})},
+		{Path: "/raw", Handlers: []gin.HandlerFunc{func(c *gin.Context) {
+			<-c.Request.Context().Done()
+		}}},
+		{Path: "/unlimited", Timeout: -1, Handlers: PackageHandlers(func(ctx context.Context) bool {
+			_, ok := ctx.Deadline()
+			return ok
+		})},
+	}}}
+	handler := newTestHandler(t)
+
+	tests := []struct {
+		path   string
+		status int
+		body   string
+	}{
+		{"/fast", http.StatusOK, `"fast"`},
+		{"/slow", http.StatusServiceUnavailable, `{"status":503,"message":"request timeout"}`},
+		{"/partial", http.StatusOK, "partial"},
+		{"/raw", http.StatusServiceUnavailable, `{"status":503,"message":"request timeout"}`},
+		{"/unlimited", http.StatusOK, "false"},
+	}
+	for _, test := range tests {
+		w := doRequest(handler, testRequest{target: test.path})
+		if w.Code != test.status || w.Body.String() != test.body {
+			t.Errorf("%s: got %d %q, want %d %q", test.path, w.Code, w.Body.String(), test.status, test.body)
+		}
+	}
+}
@@ -0,0 +1,243 @@
+package gs
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+)
+
+type RouterError struct {
+	// type of the controller which provides the router
+	Controller string
+	// paths of routers from root to the one which has problem
+	Paths   []string
+	Message string
+}
+
+func (err *RouterError) Error() string {
+	return err.location() + ": " + err.Message
+}
+
+// e.g. [controller.UserController] "/api" > "/user" > "/:id"
+func (err *RouterError) location() string {
+	paths := make([]string, 0, len(err.Paths))
+	for _, path := range err.Paths {
+		paths = append(paths, fmt.Sprintf("%q", path))
+	}
+	location := strings.Join(paths, " > ")
+	if err.Controller != "" {
+		location = "[" + err.Controller + "] " + location
+	}
+	return location
+}
+
+// all problems of a router tree
+type RouterErrors []*RouterError
+
+func (errs RouterErrors) Error() string {
+	messages := make([]string, 0, len(errs))
+	for _, err := range errs {
+		messages = append(messages, err.Error())
+	}
+	return fmt.Sprintf("%d problem(s) found in routers:\n%s", len(errs), strings.Join(messages, "\n"))
+}
+
+// registered route of a certain method, used to check duplication and conflict
+type validatedRoute struct {
+	fullPath string
+	segments []string
+	location *RouterError
+}
+
+type routerValidator struct {
+	errs RouterErrors
+	// "{host pattern} {http method}" => routes, routes of different hosts don't conflict
+	routes map[string][]validatedRoute
+	names  map[string]*RouterError
+	// whether the tree is served by admin listener
+	admin bool
+}
+
+// Check the whole router tree, report all problems instead of stopping at the first one.
+//
+// It checks problems which make gin panic (e.g. duplicate path, conflicting wildcard),
+// and settings which are ignored silently (e.g. handlers of router group).
+func ValidateRouter(gsRouter *Router) error {
+	return validateRouter("/", gsRouter)
+}
+
+func validateRouter(basePath string, gsRouter *Router) error {
+	validator := &routerValidator{
+		routes: make(map[string][]validatedRoute),
+		names:  make(map[string]*RouterError),
+	}
+	validator.validate("", basePath, gsRouter, &RouterError{})
+	if len(validator.errs) == 0 {
+		return nil
+	}
+	return validator.errs
+}
+
+func (validator *routerValidator) addError(location *RouterError, format string, a ...any) {
+	validator.errs = append(validator.errs, &RouterError{
+		Controller: location.Controller,
+		Paths:      location.Paths,
+		Message:    fmt.Sprintf(format, a...),
+	})
+}
+
+// host is the pattern of parent routers, patterns are parsed but not registered by validation
+func (validator *routerValidator) validate(host string, basePath string, gsRouter *Router, parent *RouterError) {
+	location := &RouterError{
+		Controller: parent.Controller,
+		Paths:      append(slices.Clip(parent.Paths), gsRouter.Path),
+	}
+	if gsRouter.controller != "" {
+		location.Controller = gsRouter.controller
+	}
+	if gsRouter.admin {
+		validator.admin = true
+	}
+	if gsRouter.Host != "" {
+		if validator.admin {
+			validator.addError(location, "host can't be set on routers of admin listener")
+		} else if host != "" {
+			validator.addError(location, "host can't be set on children of router bound to host")
+		} else if pattern, err := parseHostPattern(gsRouter.Host); err != nil {
+			validator.addError(location, "%s", err.Error())
+		} else {
+			host = pattern.pattern
+		}
+	}
+	fullPath := joinPaths(basePath, gsRouter.Path)
+	if gsRouter.RateLimit != nil {
+		if err := gsRouter.RateLimit.validate(); err != nil {
+			validator.addError(location, "%s", err.Error())
+		}
+	}
+	if gsRouter.CORS != nil {
+		if _, err := newCORSPolicy(gsRouter.CORS); err != nil {
+			validator.addError(location, "%s", err.Error())
+		}
+	}
+
+	if gsRouter.Method&^Any != 0 {
+		validator.addError(location, "invalid http method bitmask %#x", uint16(gsRouter.Method))
+	}
+
+	if len(gsRouter.Children) != 0 {
+		if len(gsRouter.Handlers) != 0 {
+			validator.addError(location, "handlers of router group are ignored")
+		}
+		if gsRouter.Function != nil {
+			validator.addError(location, "function of router group is ignored")
+		}
+		if gsRouter.Method != 0 {
+			validator.addError(location, "method of router group is ignored")
+		}
+		if gsRouter.Name != "" {
+			validator.addError(location, "name of router group is ignored")
+		}
+		for i := range gsRouter.Children {
+			validator.validate(host, fullPath, &gsRouter.Children[i], location)
+		}
+		return
+	}
+
+	if len(gsRouter.Handlers) == 0 && gsRouter.Function == nil {
+		validator.addError(location, "there must be at least one handler")
+	}
+	if gsRouter.Function != nil {
+		if _, err := newHandlerInfo(gsRouter.Function); err != nil {
+			validator.addError(location, "%s", err.Error())
+		}
+	}
+	if gsRouter.Name != "" {
+		if previous, ok := validator.names[gsRouter.Name]; ok {
+			validator.addError(location, "route name %q is already used by %s", gsRouter.Name, previous.location())
+		} else {
+			validator.names[gsRouter.Name] = location
+		}
+	}
+	if !strings.HasPrefix(fullPath, "/") {
+		validator.addError(location, "full path %q must begin with '/'", fullPath)
+		return
+	}
+	if !validator.validatePathSyntax(fullPath, location) {
+		return
+	}
+
+	route := validatedRoute{fullPath: fullPath, segments: strings.Split(fullPath, "/"), location: location}
+	for _, method := range (gsRouter.Method & Any).names() {
+		key := host + " " + method
+		for _, existing := range validator.routes[key] {
+			if message := getRouteConflict(&existing, &route); message != "" {
+				validator.addError(location, "%s %s%s %s (registered by %s)", method, host, fullPath, message, existing.location.location())
+			}
+		}
+		validator.routes[key] = append(validator.routes[key], route)
+	}
+}
+
+func (validator *routerValidator) validatePathSyntax(fullPath string, location *RouterError) bool {
+	segments := strings.Split(fullPath, "/")
+	for i, segment := range segments {
+		prefix, wildcard := splitWildcard(segment)
+		if wildcard == "" {
+			continue
+		}
+		if len(wildcard) == 1 {
+			validator.addError(location, "wildcard must be named with a non-empty name in path %q", fullPath)
+			return false
+		}
+		if strings.ContainsAny(wildcard[1:], ":*") {
+			validator.addError(location, "only one wildcard per path segment is allowed, has %q in path %q", segment, fullPath)
+			return false
+		}
+		if wildcard[0] == '*' && (i != len(segments)-1 || prefix != "") {
+			validator.addError(location, "catch-all wildcard is only allowed as the last segment of path %q", fullPath)
+			return false
+		}
+	}
+	return true
+}
+
+// Split segment by the first wildcard, since gin treats ':' and '*' anywhere in a segment as wildcard.
+// e.g. file:name => file, :name. wildcard is empty for static segment
+func splitWildcard(segment string) (string, string) {
+	i := strings.IndexAny(segment, ":*")
+	if i < 0 {
+		return segment, ""
+	}
+	return segment[:i], segment[i:]
+}
+
+// It returns empty string if there is no conflict.
+func getRouteConflict(existing *validatedRoute, route *validatedRoute) string {
+	if existing.fullPath == route.fullPath {
+		return "is duplicate"
+	}
+	for i := 0; i < len(existing.segments) && i < len(route.segments); i++ {
+		a, b := existing.segments[i], route.segments[i]
+		prefixA, wildcardA := splitWildcard(a)
+		prefixB, wildcardB := splitWildcard(b)
+		if strings.HasPrefix(wildcardA, "*") {
+			return fmt.Sprintf("conflicts with catch-all wildcard %q", a)
+		}
+		if strings.HasPrefix(wildcardB, "*") {
+			return fmt.Sprintf("has catch-all wildcard %q which conflicts with segment %q", b, a)
+		}
+		if prefixA == prefixB && wildcardA != "" && wildcardB != "" {
+			// wildcards at the same position must be the same
+			if wildcardA != wildcardB {
+				return fmt.Sprintf("conflicts with wildcard %q", a)
+			}
+			continue
+		}
+		if a != b {
+			// static segment and wildcard can coexist
+			return ""
+		}
+	}
+	return ""
+}
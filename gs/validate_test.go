@@ -0,0 +1,94 @@
+package gs
+
+import (
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestValidateRouter(t *testing.T) {
+	handlers := []gin.HandlerFunc{stringHandler("")}
+	router := Router{Path: "/api", Children: []Router{
+		{Path: "/user/:id", Method: GET, Handlers: handlers, Name: "user"},
+		{Path: "/user/:id", Method: GET, Handlers: handlers},
+		{Path: "/user/:uid/name", Method: GET, Handlers: handlers},
+		{Path: "/user/:id", Method: POST, Handlers: handlers, Name: "user"},
+		{Path: "/group", Method: GET, Handlers: handlers, Children: []Router{{Path: "/a", Method: GET, Handlers: handlers}}},
+		{Path: "/empty", Method: GET},
+		{Path: "/files/*path/x", Method: GET, Handlers: handlers},
+	}}
+	err := ValidateRouter(&router)
+	errs, ok := err.(RouterErrors)
+	if !ok {
+		t.Fatalf("got %v, want RouterErrors", err)
+	}
+	// duplicate, conflicting wildcard (with both routes of :id), duplicate name,
+	// handlers and method of group, no handler, catch-all
+	if len(errs) != 8 {
+		t.Errorf("got %d problems, want 8:\n%v", len(errs), err)
+	}
+}
+
+func TestAddRouterPanicsWithRouterErrors(t *testing.T) {
+	setupTest(t, nil)
+	defer func() {
+		if errs, ok := recover().(RouterErrors); !ok || len(errs) != 1 {
+			t.Errorf("got %v, want RouterErrors", errs)
+		}
+	}()
+	AddRouter(gin.New(), &Router{Path: "/a", Method: GET})
+}
+
+// Conflicts reported by validation should be the same as panics of gin.
+func TestValidateRouteConflictLikeGin(t *testing.T) {
+	tests := [][2]string{
+		{"/file:a", "/file:b"},
+		{"/file:a", "/file:a/x"},
+		{"/file:a", "/files"},
+		{"/file:a", "/fi:b"},
+		{"/user/:id", "/user/:uid"},
+		{"/user/:id", "/user/me"},
+		{"/static/*path", "/static/x"},
+		{"/a/*path", "/a:b"},
+	}
+	for _, test := range tests {
+		handlers := []gin.HandlerFunc{stringHandler("")}
+		router := Router{Path: "", Children: []Router{
+			{Path: test[0], Method: GET, Handlers: handlers},
+			{Path: test[1], Method: GET, Handlers: handlers},
+		}}
+		reported := ValidateRouter(&router) != nil
+		panicked := func() (panicked bool) {
+			defer func() { panicked = recover() != nil }()
+			engine := gin.New()
+			engine.GET(test[0], handlers...)
+			engine.GET(test[1], handlers...)
+			return false
+		}()
+		if reported != panicked {
+			t.Errorf("%s and %s: validation reported %v, gin panicked %v", test[0], test[1], reported, panicked)
+		}
+	}
+}
+
+func TestValidateInSegmentWildcardSyntax(t *testing.T) {
+	for _, path := range []string{"/file:", "/file:a:b", "/file*path"} {
+		router := Router{Path: path, Method: GET, Handlers: []gin.HandlerFunc{stringHandler("")}}
+		if ValidateRouter(&router) == nil {
+			t.Errorf("%s: got nil, want error", path)
+		}
+	}
+}
+
+func TestValidateFunction(t *testing.T) {
+	router := Router{Path: "/api", Function: func() {}, Children: []Router{
+		{Path: "/a", Method: GET, Function: func(a, b string) {}},
+		{Path: "/b", Method: GET, Function: "not a function"},
+		{Path: "/c", Method: GET, Function: func(c *gin.Context) {}},
+	}}
+	errs, ok := ValidateRouter(&router).(RouterErrors)
+	// function of group, unsupported params, not a function
+	if !ok || len(errs) != 3 {
+		t.Errorf("got %v, want 3 problems", errs)
+	}
+}
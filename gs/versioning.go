@@ -0,0 +1,229 @@
+package gs
+
+import (
+	"net/http"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+type VersionStrategy int
+
+const (
+	// version is given by path, e.g. /api/v2/user
+	VersionByPath VersionStrategy = iota
+	// version is given by media type of Accept header,
+	// e.g. application/vnd.{vendor}.v2+json or application/json; version=v2
+	VersionByAccept
+	// version is given by custom header, e.g. X-API-Version: v2
+	VersionByHeader
+)
+
+type Versioning struct {
+	Strategy VersionStrategy
+	// header name for VersionByHeader. default value is X-API-Version
+	Header string
+	// vendor of media type for VersionByAccept. if empty, any vendor is accepted
+	Vendor string
+	// used when request doesn't specify version. default value is the last version
+	Default string
+}
+
+type ControllerVersion struct {
+	// path segment of version, e.g. v1
+	Version    string
+	Controller Controller
+	// same as `Router.Deprecated` and `Router.Sunset`
+	Deprecated bool
+	Sunset     time.Time
+}
+
+type versionedControllers struct {
+	versioning Versioning
+	versions   []ControllerVersion
+	// paths of controllers' routers, used to match requests without version
+	paths []string
+}
+
+var versionedControllerList = make([]*versionedControllers, 0)
+
+// Register multiple versions of a controller.
+// Routers of each version are mounted under /{version}, e.g. /api/v1/user and /api/v2/user.
+//
+// Requests without version in path, e.g. /api/user, are dispatched to the version given by
+// Accept header or custom header (depending on `versioning.Strategy`), or the default version.
+// Versioned path is always available, regardless of the strategy.
+func UseVersionedController(versioning Versioning, versions ...ControllerVersion) {
+	if len(versions) == 0 {
+		return
+	}
+	if versioning.Header == "" {
+		versioning.Header = "X-API-Version"
+	}
+	if versioning.Default == "" {
+		versioning.Default = versions[len(versions)-1].Version
+	}
+
+	item := &versionedControllers{versioning: versioning, versions: versions}
+	for _, version := range versions {
+		router := version.Controller.GetRouter()
+		item.paths = append(item.paths, router.Path)
+		UseController(versionController{version: version, router: router, versioning: versioning})
+	}
+	versionedControllerList = append(versionedControllerList, item)
+}
+
+type versionController struct {
+	version    ControllerVersion
+	router     Router
+	versioning Versioning
+}
+
+func (controller versionController) GetRouter() Router {
+	controller.router.controller = getTypeName(controller.version.Controller)
+	router := Router{
+		Path:       "/" + controller.version.Version,
+		Children:   []Router{controller.router},
+		Deprecated: controller.version.Deprecated,
+		Sunset:     controller.version.Sunset,
+	}
+	switch controller.versioning.Strategy {
+	case VersionByAccept:
+		router.MiddleWares = []gin.HandlerFunc{varyMiddleware("Accept")}
+	case VersionByHeader:
+		router.MiddleWares = []gin.HandlerFunc{varyMiddleware(controller.versioning.Header)}
+	}
+	return router
+}
+
+func varyMiddleware(header string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		c.Writer.Header().Add("Vary", header)
+	}
+}
+
+// get version given by request header, it is empty if not given.
+func (versioning *Versioning) getRequestVersion(req *http.Request) string {
+	switch versioning.Strategy {
+	case VersionByHeader:
+		return strings.TrimSpace(req.Header.Get(versioning.Header))
+	case VersionByAccept:
+		for _, mediaRange := range strings.Split(req.Header.Get("Accept"), ",") {
+			if version := versioning.getMediaTypeVersion(mediaRange); version != "" {
+				return version
+			}
+		}
+	}
+	return ""
+}
+
+func (versioning *Versioning) getMediaTypeVersion(mediaRange string) string {
+	parts := strings.Split(mediaRange, ";")
+	for _, param := range parts[1:] {
+		if key, value, ok := strings.Cut(strings.TrimSpace(param), "="); ok && key == "version" {
+			return strings.Trim(value, `"`)
+		}
+	}
+
+	// application/vnd.{vendor}.{version}+json
+	mediaType := strings.TrimSpace(parts[0])
+	subType, ok := strings.CutPrefix(mediaType, "application/vnd.")
+	if !ok {
+		return ""
+	}
+	subType, _, _ = strings.Cut(subType, "+")
+	index := strings.LastIndex(subType, ".")
+	if index < 0 {
+		return ""
+	}
+	if versioning.Vendor != "" && subType[:index] != versioning.Vendor {
+		return ""
+	}
+	return subType[index+1:]
+}
+
+// Insert version into the path of request which has no version, return false if
+// it doesn't belong to any versioned controller.
+func rewriteVersionPath(req *http.Request) bool {
+	rest, ok := strings.CutPrefix(req.URL.Path, strings.TrimSuffix(rootRouter.Path, "/"))
+	if !ok {
+		return false
+	}
+	for _, item := range versionedControllerList {
+		if item.hasVersion(rest) || !item.matchPath(rest) {
+			continue
+		}
+		version := item.versioning.getRequestVersion(req)
+		if version == "" {
+			version = item.versioning.Default
+		}
+		req.URL.Path = joinPaths(strings.TrimSuffix(rootRouter.Path, "/")+"/"+version, rest)
+		req.URL.RawPath = ""
+		return true
+	}
+	return false
+}
+
+func (item *versionedControllers) hasVersion(path string) bool {
+	for _, version := range item.versions {
+		if path == "/"+version.Version || strings.HasPrefix(path, "/"+version.Version+"/") {
+			return true
+		}
+	}
+	return false
+}
+
+func (item *versionedControllers) matchPath(path string) bool {
+	for _, prefix := range item.paths {
+		prefix = "/" + strings.Trim(prefix, "/")
+		if prefix == "/" || path == prefix || strings.HasPrefix(path, prefix+"/") {
+			return true
+		}
+	}
+	return false
+}
+
+// Dispatch requests without version to versioned controllers, by rewriting path before routing of gin
+// (so global middlewares run once). Requests matched by other routes of the engine are not rewritten.
+func newVersionHandler(engine *gin.Engine, handler http.Handler) http.Handler {
+	if len(versionedControllerList) == 0 {
+		return handler
+	}
+	// method => full paths of routes
+	routePaths := make(map[string][]string)
+	for _, route := range engine.Routes() {
+		routePaths[route.Method] = append(routePaths[route.Method], route.Path)
+	}
+	return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		if !slices.ContainsFunc(routePaths[req.Method], func(fullPath string) bool {
+			return matchFullPath(fullPath, req.URL.Path)
+		}) {
+			rewriteVersionPath(req)
+		}
+		handler.ServeHTTP(w, req)
+	})
+}
+
+// Whether the path is matched by full path of route, e.g. /user/:id matches /user/1.
+func matchFullPath(fullPath string, urlPath string) bool {
+	patternSegments, segments := strings.Split(fullPath, "/"), strings.Split(urlPath, "/")
+	for i, pattern := range patternSegments {
+		if i >= len(segments) {
+			return false
+		}
+		prefix, wildcard := splitWildcard(pattern)
+		if strings.HasPrefix(wildcard, "*") {
+			return true
+		}
+		if wildcard == "" {
+			if pattern != segments[i] {
+				return false
+			}
+		} else if rest, ok := strings.CutPrefix(segments[i], prefix); !ok || rest == "" {
+			return false
+		}
+	}
+	return len(patternSegments) == len(segments)
+}
@@ -0,0 +1,78 @@
+package gs
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/dan-kuroto/gin-stronger/config"
+	"github.com/gin-gonic/gin"
+)
+
+type versionTestController struct {
+	version string
+}
+
+func (controller versionTestController) GetRouter() Router {
+	return Router{Path: "/user", Children: []Router{
+		{Path: "/:id", Method: GET, Handlers: []gin.HandlerFunc{func(c *gin.Context) {
+			c.String(http.StatusOK, controller.version+" "+c.Param("id"))
+		}}},
+	}}
+}
+
+func TestVersioning(t *testing.T) {
+	setupTest(t, func(cfg *config.Configuration) {
+		cfg.CORS.Enable = true
+		cfg.CORS.AllowOrigins = []string{"*"}
+	})
+	SetGlobalPreffix("/api")
+	UseVersionedController(Versioning{Strategy: VersionByHeader},
+		ControllerVersion{Version: "v1", Controller: versionTestController{"v1"}},
+		ControllerVersion{Version: "v2", Controller: versionTestController{"v2"}},
+	)
+	rootRouter.Children = append(rootRouter.Children,
+		Router{Path: "/user/me", Method: GET, Handlers: []gin.HandlerFunc{stringHandler("me")}})
+	handler := newTestHandler(t)
+
+	tests := []struct {
+		path, version, body string
+	}{
+		{"/api/v1/user/1", "", "v1 1"},
+		{"/api/user/1", "", "v2 1"},
+		{"/api/user/1", "v1", "v1 1"},
+		// routes of the engine are not rewritten
+		{"/api/user/me", "v1", "me"},
+	}
+	for _, test := range tests {
+		w := doRequest(handler, testRequest{target: test.path, header: map[string]string{
+			"X-API-Version": test.version, "Origin": "https://example.com",
+		}})
+		if w.Code != http.StatusOK || w.Body.String() != test.body {
+			t.Errorf("%s version %q: got %d %q, want %q", test.path, test.version, w.Code, w.Body.String(), test.body)
+		}
+		// global middlewares run only once
+		if vary := w.Header().Values("Vary"); len(vary) > 2 {
+			t.Errorf("%s version %q: got Vary %v", test.path, test.version, vary)
+		}
+	}
+}
+
+func TestMatchFullPath(t *testing.T) {
+	tests := []struct {
+		fullPath, path string
+		want           bool
+	}{
+		{"/user/:id", "/user/1", true},
+		{"/user/:id", "/user/", false},
+		{"/user/:id", "/user/1/x", false},
+		{"/static/*filepath", "/static/a/b", true},
+		{"/static/*filepath", "/static", false},
+		{"/user", "/user", true},
+		{"/user", "/users", false},
+	}
+	for _, test := range tests {
+		if got := matchFullPath(test.fullPath, test.path); got != test.want {
+			t.Errorf("%s %s: got %v", test.fullPath, test.path, got)
+		}
+	}
+}